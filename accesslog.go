@@ -0,0 +1,160 @@
+package revel
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+var (
+	// The access log, configured by log.access.* in app.conf, or nil if there
+	// is none.
+	accessLog *log.Logger
+
+	// The format of the access log: "combined" (the default) or "json".
+	accessLogFormat string
+)
+
+func init() {
+	OnAppStart(func() {
+		accessLog = nil
+		if _, found := Config.String("log.access.output"); !found {
+			return
+		}
+		accessLog = getLogger("access")
+		if _, found := Config.Int("log.access.flags"); !found {
+			accessLog.SetFlags(0) // Each line has its own timestamp.
+		}
+		accessLogFormat = Config.StringDefault("log.access.format", "combined")
+	})
+}
+
+// accessLogWriter records the status and size of the response.
+type accessLogWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *accessLogWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// AccessLogFilter writes a line to the access log for each request.  It is
+// enabled by setting its output, using the same targets as the other logs:
+//   log.access.output = /var/log/myapp/access.log
+//   log.access.format = json
+//
+// Lines are in the Apache combined format, followed by the action, the
+// duration in microseconds and the session ID, or else are JSON objects.
+//
+// The filter applies the result itself, so that it can record the response.
+// It should come first, so that the whole request is timed.
+func AccessLogFilter(c *Controller, fc []Filter) {
+	if accessLog == nil {
+		fc[0](c, fc[1:])
+		return
+	}
+
+	start := time.Now()
+	writer := &accessLogWriter{ResponseWriter: c.Response.Out}
+	c.Response.Out = writer
+
+	fc[0](c, fc[1:])
+	if c.Result != nil {
+		c.Result.Apply(c.Request, c.Response)
+		c.Result = nil
+	}
+
+	entry := newAccessLogEntry(c, writer, start)
+	if accessLogFormat == "json" {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			ERROR.Println("Failed to write access log:", err)
+			return
+		}
+		accessLog.Println(string(line))
+		return
+	}
+	accessLog.Println(entry.combined())
+}
+
+type accessLogEntry struct {
+	Time      time.Time `json:"time"`
+	Remote    string    `json:"remote"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Proto     string    `json:"proto"`
+	Status    int       `json:"status"`
+	Bytes     int       `json:"bytes"`
+	Referer   string    `json:"referer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	Action    string    `json:"action,omitempty"`
+	Duration  int64     `json:"duration_us"`
+	Session   string    `json:"session,omitempty"`
+	RequestId string    `json:"request_id,omitempty"`
+}
+
+func newAccessLogEntry(c *Controller, w *accessLogWriter, start time.Time) *accessLogEntry {
+	remote, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		remote = c.Request.RemoteAddr
+	}
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	// Read the session ID rather than calling Session.Id, which would make up a
+	// new one after the session cookie has been written.
+	session := c.Session[SESSION_ID_KEY]
+	return &accessLogEntry{
+		Time:      start,
+		Remote:    remote,
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.RequestURI(),
+		Proto:     c.Request.Proto,
+		Status:    status,
+		Bytes:     w.bytes,
+		Referer:   c.Request.Referer(),
+		UserAgent: c.Request.UserAgent(),
+		Action:    c.Action,
+		Duration:  int64(time.Since(start) / time.Microsecond),
+		Session:   session,
+		RequestId: c.RequestId,
+	}
+}
+
+// combined returns the entry in the Apache combined log format, followed by
+// the action, duration and session.
+func (e *accessLogEntry) combined() string {
+	bytes := "-"
+	if e.Bytes > 0 {
+		bytes = fmt.Sprint(e.Bytes)
+	}
+	return fmt.Sprintf(`%s - - [%s] %q %d %s %q %q %q %d %q`,
+		orDash(e.Remote), e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		e.Method+" "+e.Path+" "+e.Proto, e.Status, bytes,
+		orDash(e.Referer), orDash(e.UserAgent), orDash(e.Action), e.Duration, orDash(e.Session))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
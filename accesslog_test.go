@@ -0,0 +1,52 @@
+package revel
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+func TestAccessLogFilter(t *testing.T) {
+	startFakeBookingApp()
+	var out bytes.Buffer
+	defer func(logger *log.Logger, format string) {
+		accessLog, accessLogFormat = logger, format
+	}(accessLog, accessLogFormat)
+	accessLog = log.New(&out, "", 0)
+
+	serve := func() {
+		req := testRequest("GET", "/hotels/3?x=1", "User-Agent", `Test "Agent"`)
+		req.RemoteAddr = "1.2.3.4:5678"
+		c, _ := testController(req)
+		c.Session = Session{SESSION_ID_KEY: "abc"}
+		c.Action = "Hotels.Show"
+		AccessLogFilter(c, []Filter{func(c *Controller, _ []Filter) {
+			c.Response.Status = http.StatusCreated
+			c.Result = c.RenderText("hello")
+		}})
+	}
+
+	accessLogFormat = "combined"
+	serve()
+	pattern := `^1\.2\.3\.4 - - \[[^\]]+\] "GET /hotels/3\?x=1 HTTP/1\.1" 201 5 "-" "Test \\"Agent\\"" "Hotels\.Show" \d+ "abc"\n$`
+	if !regexp.MustCompile(pattern).MatchString(out.String()) {
+		t.Errorf("Unexpected combined log line: %s", out.String())
+	}
+
+	out.Reset()
+	accessLogFormat = "json"
+	serve()
+	var entry accessLogEntry
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse JSON log line %s: %s", out.String(), err)
+	}
+	eq(t, "Remote", entry.Remote, "1.2.3.4")
+	eq(t, "Path", entry.Path, "/hotels/3?x=1")
+	eq(t, "Status", entry.Status, http.StatusCreated)
+	eq(t, "Bytes", entry.Bytes, 5)
+	eq(t, "Action", entry.Action, "Hotels.Show")
+	eq(t, "Session", entry.Session, "abc")
+}
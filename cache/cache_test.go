@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"context"
 	"math"
 	"testing"
 	"time"
@@ -243,3 +244,11 @@ func testGetMulti(t *testing.T, newCache cacheFactory) {
 		t.Errorf("Error getting foo: %s / %v", err, foo)
 	}
 }
+
+func testRoundTrip(t *testing.T, newCache cacheFactory) {
+	defer func(instance Cache) { Instance = instance }(Instance)
+	Instance = newCache(t, time.Hour)
+	if err := roundTrip(context.Background()); err != nil {
+		t.Errorf("Unexpected error in the health check round trip: %s", err)
+	}
+}
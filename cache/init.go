@@ -1,13 +1,19 @@
 package cache
 
 import (
+	"context"
+	"fmt"
 	"github.com/robfig/revel"
+	"github.com/robfig/revel/health"
 	"strings"
 	"time"
 )
 
 func init() {
 	revel.OnAppStart(func() {
+		// Report on the cache's health.  The app may run (slowly) without it.
+		health.RegisterNonCritical("cache", roundTrip)
+
 		// Set the default expiration time.
 		defaultExpiration := time.Hour // The default for the default is one hour.
 		if expireStr, found := revel.Config.String("cache.expires"); found {
@@ -32,3 +38,21 @@ func init() {
 		Instance = NewInMemoryCache(defaultExpiration)
 	})
 }
+
+// roundTrip checks that a value written to the cache can be read back.
+func roundTrip(ctx context.Context) error {
+	sent := time.Now().UnixNano()
+	key := fmt.Sprintf("revel.health.%d", sent)
+	if err := Instance.Set(key, sent, time.Minute); err != nil {
+		return err
+	}
+	defer Instance.Delete(key)
+	var received int64
+	if err := Instance.Get(key, &received); err != nil {
+		return err
+	}
+	if received != sent {
+		return fmt.Errorf("read %d from the cache, expected %d", received, sent)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"fmt"
+	"github.com/robfig/revel"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A rateLimit allows a number of requests per period.
+type rateLimit struct {
+	scope  string // "" for the global limit, or e.g. "hotels.book"
+	limit  uint64
+	period time.Duration
+}
+
+var (
+	// Limits from ratelimit in app.conf, by lower-cased controller or action.
+	// The global limit is under "".
+	rateLimits map[string]*rateLimit
+
+	// How clients are told apart: by "ip" (the default) or by "session".
+	rateLimitBy string
+)
+
+func init() {
+	revel.OnAppStart(func() {
+		// Parse the limits up front, so that an invalid one stops the app from
+		// starting.  ratelimit.by names no controller.
+		rateLimits = make(map[string]*rateLimit)
+		options := revel.Config.ActionOptions("ratelimit")
+		delete(options, "by")
+		for scope, value := range options {
+			limit, err := parseRateLimit(scope, value)
+			if err != nil {
+				panic(fmt.Errorf("ratelimit%s invalid: %s", strings.TrimRight("."+scope, "."), err))
+			}
+			rateLimits[scope] = limit
+		}
+		rateLimitBy = revel.Config.StringDefault("ratelimit.by", "ip")
+	})
+}
+
+// parseRateLimit parses a limit of the form "requests/period", e.g. "100/1m".
+// A limit of 0 allows any number of requests.
+func parseRateLimit(scope, value string) (*rateLimit, error) {
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("expected requests/period, e.g. 100/1m, got %q", value)
+	}
+	limit, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return nil, err
+	}
+	if period < time.Second {
+		return nil, fmt.Errorf("period must be at least 1s, got %s", period)
+	}
+	return &rateLimit{scope, limit, period}, nil
+}
+
+// findRateLimit returns the limit for the action, or nil if there is none.
+func findRateLimit(action string) *rateLimit {
+	for _, key := range revel.ActionConfigKeys(action) {
+		if limit, ok := rateLimits[key]; ok {
+			return limit
+		}
+	}
+	return nil
+}
+
+// RateLimitFilter throttles clients making too many requests, as configured
+// in app.conf.  For example:
+//   ratelimit = 600/1m
+//   ratelimit.Application.Login = 5/1m
+//   ratelimit.by = session
+//
+// Limits for a controller or action are counted separately from the global
+// limit.  The filter must come after the RouterFilter, which finds the action.
+// Clients are told apart by IP address, or by session, in which case the filter
+// must also come after the SessionFilter.
+//
+// The count over a sliding window is kept in the cache, so that it is shared
+// by all the instances of an app using memcached.  Responses carry the
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers.
+// Throttled requests are answered with 429 Too Many Requests, and a
+// Retry-After header.
+func RateLimitFilter(c *revel.Controller, fc []revel.Filter) {
+	limit := findRateLimit(c.Action)
+	if limit == nil || limit.limit == 0 {
+		fc[0](c, fc[1:])
+		return
+	}
+
+	count, reset, err := limit.hit(rateLimitClient(c), time.Now())
+	if err != nil {
+		// Let the request through, rather than fail because of the cache.
+		revel.ERROR.Println("Rate limit:", err)
+		fc[0](c, fc[1:])
+		return
+	}
+
+	header := c.Response.Out.Header()
+	header.Set("X-RateLimit-Limit", strconv.FormatUint(limit.limit, 10))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	if count > limit.limit {
+		header.Set("X-RateLimit-Remaining", "0")
+		header.Set("Retry-After", strconv.Itoa(int(reset.Sub(time.Now())/time.Second)+1))
+		c.Response.Status = http.StatusTooManyRequests
+		c.Result = c.RenderError(&revel.Error{
+			Title:       "Too Many Requests",
+			Description: fmt.Sprintf("More than %d requests per %s", limit.limit, limit.period),
+		})
+		return
+	}
+	header.Set("X-RateLimit-Remaining", strconv.FormatUint(limit.limit-count, 10))
+	fc[0](c, fc[1:])
+}
+
+// rateLimitClient returns the key identifying the client making the request.
+func rateLimitClient(c *revel.Controller) string {
+	if rateLimitBy == "session" && c.Session != nil {
+		return "session:" + c.Session.Id()
+	}
+	host, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		host = c.Request.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// hit counts a request by the client, returning the number of requests it has
+// made over the last period, and when the current window ends.
+//
+// Requests are counted in fixed windows.  The count over the last period is
+// estimated from the current window's count, plus the previous window's count
+// weighted by how much of it falls within the period.
+func (l *rateLimit) hit(client string, now time.Time) (count uint64, reset time.Time, err error) {
+	var (
+		window  = now.UnixNano() / int64(l.period)
+		key     = fmt.Sprintf("revel.ratelimit.%s.%s.%d", l.scope, client, window)
+		prevKey = fmt.Sprintf("revel.ratelimit.%s.%s.%d", l.scope, client, window-1)
+		elapsed = time.Duration(now.UnixNano() - window*int64(l.period))
+	)
+	reset = time.Unix(0, (window+1)*int64(l.period))
+
+	current, err := incrementOrAdd(key, 2*l.period)
+	if err != nil {
+		return 0, reset, err
+	}
+
+	// Incrementing by zero reads the count, whichever backend stored it.
+	previous, err := Increment(prevKey, 0)
+	if err == ErrCacheMiss {
+		previous, err = 0, nil
+	}
+	if err != nil {
+		return 0, reset, err
+	}
+
+	weight := float64(l.period-elapsed) / float64(l.period)
+	return current + uint64(float64(previous)*weight), reset, nil
+}
+
+// incrementOrAdd increments the counter, adding it if it does not exist.
+func incrementOrAdd(key string, expires time.Duration) (uint64, error) {
+	count, err := Increment(key, 1)
+	if err != ErrCacheMiss {
+		return count, err
+	}
+	if err = Add(key, uint64(1), expires); err != ErrNotStored {
+		return 1, err
+	}
+	// Another request added it first.
+	return Increment(key, 1)
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"github.com/robfig/revel"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	limit, err := parseRateLimit("hotels.book", "5 / 1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limit.limit != 5 || limit.period != time.Minute {
+		t.Errorf("Expected 5/1m, got %d/%s", limit.limit, limit.period)
+	}
+
+	for _, value := range []string{"5", "five/1m", "5/minute", "5/100ms"} {
+		if _, err := parseRateLimit("", value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestRateLimitSlidingWindow(t *testing.T) {
+	defer func(cache Cache) { Instance = cache }(Instance)
+	Instance = NewInMemoryCache(time.Hour)
+	limit := &rateLimit{"", 10, time.Minute}
+	start := time.Unix(0, 0).Add(1000 * time.Minute)
+
+	// Make 8 requests early in a window.
+	var count uint64
+	for i := 0; i < 8; i++ {
+		count, _, _ = limit.hit("ip:1.2.3.4", start.Add(time.Second))
+	}
+	if count != 8 {
+		t.Errorf("Expected 8 requests, got %d", count)
+	}
+
+	// Other clients are counted separately.
+	if count, _, _ = limit.hit("ip:5.6.7.8", start.Add(time.Second)); count != 1 {
+		t.Errorf("Expected 1 request from another client, got %d", count)
+	}
+
+	// A quarter of the way into the next window, 3/4 of the previous window's
+	// requests still count.
+	count, reset, err := limit.hit("ip:1.2.3.4", start.Add(75*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1+6 {
+		t.Errorf("Expected 7 requests, got %d", count)
+	}
+	if !reset.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("Expected the window to end at %s, got %s", start.Add(2*time.Minute), reset)
+	}
+
+	// Two windows later, nothing is left.
+	if count, _, _ = limit.hit("ip:1.2.3.4", start.Add(3*time.Minute)); count != 1 {
+		t.Errorf("Expected 1 request, got %d", count)
+	}
+}
+
+func TestRateLimitFilter(t *testing.T) {
+	defer func(cache Cache, limits map[string]*rateLimit) {
+		Instance, rateLimits = cache, limits
+	}(Instance, rateLimits)
+	Instance = NewInMemoryCache(time.Hour)
+	rateLimits = map[string]*rateLimit{
+		"":                  {"", 100, time.Hour},
+		"application.login": {"application.login", 2, time.Hour},
+	}
+
+	serve := func(action string) (*revel.Controller, http.Header) {
+		req, _ := http.NewRequest("POST", "/login", nil)
+		req.RemoteAddr = "1.2.3.4:5678"
+		resp := httptest.NewRecorder()
+		c := revel.NewController(revel.NewRequest(req), revel.NewResponse(resp))
+		c.Action = action
+		RateLimitFilter(c, revel.NilChain)
+		return c, resp.Header()
+	}
+
+	for i, remaining := range []string{"1", "0"} {
+		c, header := serve("Application.Login")
+		if c.Result != nil {
+			t.Fatalf("Request %d was throttled", i+1)
+		}
+		if header.Get("X-RateLimit-Limit") != "2" || header.Get("X-RateLimit-Remaining") != remaining {
+			t.Errorf("Unexpected headers for request %d: %v", i+1, header)
+		}
+	}
+
+	c, header := serve("Application.Login")
+	if c.Result == nil || c.Response.Status != http.StatusTooManyRequests {
+		t.Errorf("Expected the third request to be throttled, got %d", c.Response.Status)
+	}
+	if header.Get("Retry-After") == "" || header.Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("Unexpected headers for a throttled request: %v", header)
+	}
+
+	// Other actions fall under the global limit.
+	if c, header = serve("Hotels.Index"); c.Result != nil || header.Get("X-RateLimit-Remaining") != "99" {
+		t.Errorf("Expected the global limit to apply, got %v", header)
+	}
+}
@@ -47,6 +47,13 @@ func CompressFilter(c *Controller, fc []Filter) {
 
 func (c *CompressResponseWriter) prepareHeaders() {
 	if c.compressionType != "" {
+		// A strong ETag must differ between the compressed and uncompressed
+		// responses, so make it weak.  This is done whether or not the response
+		// is compressed, so that a 304 carries the same tag.
+		if etag := c.Header().Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			c.Header().Set("ETag", "W/"+etag)
+		}
+
 		responseMime := c.Header().Get("Content-Type")
 		responseMime = strings.TrimSpace(strings.SplitN(responseMime, ";", 2)[0])
 		shouldEncode := false
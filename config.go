@@ -5,6 +5,7 @@ import (
 	"github.com/robfig/config"
 	"path"
 	"strings"
+	"time"
 )
 
 // This handles the parsing of app.conf
@@ -98,6 +99,27 @@ func (c *MergedConfig) StringDefault(option, dfault string) string {
 	return dfault
 }
 
+// Duration returns the option parsed as a duration, e.g. "30s" or "1m30s".
+func (c *MergedConfig) Duration(option string) (result time.Duration, found bool) {
+	r, found := c.String(option)
+	if !found {
+		return 0, false
+	}
+	result, err := time.ParseDuration(r)
+	if err != nil {
+		ERROR.Println("Failed to parse config option", option, "as duration:", err)
+		return 0, false
+	}
+	return result, true
+}
+
+func (c *MergedConfig) DurationDefault(option string, dfault time.Duration) time.Duration {
+	if r, found := c.Duration(option); found {
+		return r
+	}
+	return dfault
+}
+
 func (c *MergedConfig) HasSection(section string) bool {
 	return c.config.HasSection(section)
 }
@@ -115,6 +137,36 @@ func (c *MergedConfig) Options(prefix string) []string {
 	return options
 }
 
+// ActionOptions returns the option named by the prefix, and the options under
+// it for particular controllers and actions, e.g.
+//   ratelimit = 600/1m
+//   ratelimit.Application.Login = 5/1m
+// They are keyed by the lower-cased controller or action ("application.login"),
+// or by "" for the prefix itself, as returned by ActionConfigKeys.
+func (c *MergedConfig) ActionOptions(prefix string) map[string]string {
+	options := make(map[string]string)
+	for _, key := range c.Options(prefix) {
+		name := key[len(prefix):]
+		if name != "" && name[0] != '.' {
+			continue
+		}
+		options[strings.ToLower(strings.TrimPrefix(name, "."))], _ = c.String(key)
+	}
+	return options
+}
+
+// ActionConfigKeys returns the keys of the options from ActionOptions that
+// apply to the action (e.g. "Application.Login"), most specific first: the
+// action, its controller, and "".
+func ActionConfigKeys(action string) []string {
+	action = strings.ToLower(action)
+	keys := []string{action}
+	if dot := strings.Index(action, "."); dot != -1 {
+		keys = append(keys, action[:dot])
+	}
+	return append(keys, "")
+}
+
 // Helpers
 
 func stripQuotes(s string) string {
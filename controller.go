@@ -20,6 +20,7 @@ type Controller struct {
 	MethodType    *MethodType     // A description of the invoked action type.
 	AppController interface{}     // The controller that was instantiated.
 	Action        string          // The fully qualified action name, e.g. "App.Index"
+	RequestId     string          // The ID of the request, echoed in the response.
 
 	Request  *Request
 	Response *Response
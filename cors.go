@@ -0,0 +1,196 @@
+package revel
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The CORS configuration, from app.conf.
+var (
+	corsOrigins     []string // Allowed origins, e.g. "https://*.example.com", or "*"
+	corsMethods     []string
+	corsHeaders     []string
+	corsCredentials bool
+	corsMaxAge      time.Duration
+
+	// Whether the CORSFilter is in revel.Filters, and so applies to the actions
+	// whose filters are not configured.
+	corsInFilters bool
+)
+
+func init() {
+	OnAppStart(func() {
+		corsOrigins = splitList(Config.StringDefault("cors.origins", ""))
+		corsMethods = splitList(strings.ToUpper(Config.StringDefault("cors.methods", "GET, HEAD, POST")))
+		corsHeaders = splitList(Config.StringDefault("cors.headers", "Accept, Content-Type, X-Requested-With"))
+		corsCredentials = Config.BoolDefault("cors.credentials", false)
+		corsMaxAge = Config.DurationDefault("cors.maxage", 0)
+		if corsCredentials && ContainsString(corsOrigins, "*") {
+			ERROR.Println("cors.credentials is ignored, as cors.origins allows any origin.  List the origins to allow instead.")
+			corsCredentials = false
+		}
+
+		corsInFilters = false
+		for _, f := range Filters {
+			corsInFilters = corsInFilters || FilterEq(f, CORSFilter)
+		}
+	})
+}
+
+// CORSFilter allows cross-origin requests from the origins configured in
+// app.conf.  For example:
+//   cors.origins = https://app.example.com, https://*.example.org
+//   cors.methods = GET, POST, PUT, DELETE
+//   cors.headers = Content-Type, Authorization
+//   cors.credentials = true
+//   cors.maxage = 1h
+//
+// Credentials are not allowed along with any origin ("*").
+//
+// Placed before the RouterFilter, it applies to every action, and answers
+// preflight requests without routing them.  Placed after the
+// FilterConfiguringFilter, it may be narrowed per controller or action:
+//   revel.FilterController(Admin{}).
+//     Remove(revel.CORSFilter)
+//
+// In that case, the RouterFilter answers preflight requests for the actions
+// that keep it.
+func CORSFilter(c *Controller, fc []Filter) {
+	varyOrigin(c.Response.Out.Header())
+	origin := c.Request.Header.Get("Origin")
+	if origin == "" {
+		fc[0](c, fc[1:])
+		return
+	}
+
+	if isPreflight(c.Request.Request) {
+		corsPreflight(c)
+		return
+	}
+
+	if corsOriginAllowed(origin) {
+		setCorsOrigin(c.Response.Out.Header(), origin)
+	}
+	fc[0](c, fc[1:])
+}
+
+// isPreflight returns true if the request asks whether a cross-origin request
+// is allowed.
+func isPreflight(r *http.Request) bool {
+	return r.Method == "OPTIONS" &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// corsPreflight answers a preflight request.  If the origin or method is not
+// allowed, the response carries no CORS headers, and the browser refuses to
+// send the request.
+func corsPreflight(c *Controller) {
+	var (
+		header = c.Response.Out.Header()
+		origin = c.Request.Header.Get("Origin")
+		method = c.Request.Header.Get("Access-Control-Request-Method")
+	)
+	varyOrigin(header)
+	if corsOriginAllowed(origin) && ContainsString(corsMethods, method) {
+		setCorsOrigin(header, origin)
+		header.Set("Access-Control-Allow-Methods", strings.Join(corsMethods, ", "))
+		if len(corsHeaders) > 0 {
+			header.Set("Access-Control-Allow-Headers", strings.Join(corsHeaders, ", "))
+		}
+		if corsMaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(int(corsMaxAge.Seconds())))
+		}
+	}
+	c.Response.Status = http.StatusNoContent
+	c.Result = c.RenderText("")
+}
+
+// routedPreflight answers a preflight request for a path that has no OPTIONS
+// route, if the action that would serve the requested method has the
+// CORSFilter in its filter chain.
+func routedPreflight(c *Controller) bool {
+	if !isPreflight(c.Request.Request) {
+		return false
+	}
+	req := *c.Request.Request
+	req.Method = strings.ToUpper(c.Request.Header.Get("Access-Control-Request-Method"))
+	route := MainRouter.Route(&req)
+	if route == nil || route.ControllerName == "" {
+		return false
+	}
+	if err := c.SetAction(route.ControllerName, route.MethodName); err != nil {
+		return false
+	}
+
+	chain := getOverrideChain(c.Name, c.Action)
+	if chain == nil {
+		if !corsInFilters {
+			return false
+		}
+		corsPreflight(c)
+		return true
+	}
+	for _, f := range chain {
+		if FilterEq(f, CORSFilter) {
+			corsPreflight(c)
+			return true
+		}
+	}
+	return false
+}
+
+func corsOriginAllowed(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range corsOrigins {
+		if pattern == "*" {
+			return true
+		}
+		if ok, _ := path.Match(strings.ToLower(pattern), origin); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// setCorsOrigin allows the origin to read the response.  The origin is echoed
+// back, unless any origin is allowed.
+func setCorsOrigin(header http.Header, origin string) {
+	if ContainsString(corsOrigins, "*") {
+		header.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	header.Set("Access-Control-Allow-Origin", origin)
+	if corsCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// varyOrigin tells caches that the response depends on the request's origin,
+// unless none or any origin is allowed.  This applies whether or not the origin is
+// allowed, so that a response without CORS headers is not served to another.
+func varyOrigin(header http.Header) {
+	if len(corsOrigins) == 0 || ContainsString(corsOrigins, "*") {
+		return
+	}
+	for _, value := range header["Vary"] {
+		if value == "Origin" {
+			return
+		}
+	}
+	header.Add("Vary", "Origin")
+}
+
+// splitList splits a comma-separated config value, dropping empty elements.
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
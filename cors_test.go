@@ -0,0 +1,85 @@
+package revel
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCORSFilter(t *testing.T) {
+	startFakeBookingApp()
+	defer setConfig(map[string]string{
+		"cors.origins": "https://app.example.com, https://*.example.org",
+		"cors.methods": "GET, POST",
+		"cors.maxage":  "1h",
+	})()
+
+	serve := func(method, origin, requestMethod string) (*Controller, http.Header) {
+		c, resp := testController(testRequest(method, "/hotels/3/booking",
+			"Origin", origin, "Access-Control-Request-Method", requestMethod))
+		CORSFilter(c, NilChain)
+		return c, resp.Header()
+	}
+
+	// Allowed preflight requests are answered with the configuration.
+	for _, origin := range []string{"https://app.example.com", "https://www.example.org"} {
+		c, header := serve("OPTIONS", origin, "POST")
+		eq(t, "Status", c.Response.Status, http.StatusNoContent)
+		eq(t, "Allow-Origin", header.Get("Access-Control-Allow-Origin"), origin)
+		eq(t, "Allow-Methods", header.Get("Access-Control-Allow-Methods"), "GET, POST")
+		eq(t, "Max-Age", header.Get("Access-Control-Max-Age"), "3600")
+	}
+
+	// Others are answered without the headers.
+	for _, test := range [][2]string{
+		{"https://evil.com", "POST"},
+		{"https://app.example.com.evil.com", "POST"},
+		{"https://app.example.com", "DELETE"},
+	} {
+		c, header := serve("OPTIONS", test[0], test[1])
+		eq(t, "Status", c.Response.Status, http.StatusNoContent)
+		eq(t, "Allow-Origin for "+test[0]+" "+test[1], header.Get("Access-Control-Allow-Origin"), "")
+		eq(t, "Vary", header.Get("Vary"), "Origin")
+	}
+
+	// Actual requests are passed on, with the origin allowed.
+	c, header := serve("GET", "https://app.example.com", "")
+	eq(t, "Result", c.Result, nil)
+	eq(t, "Allow-Origin", header.Get("Access-Control-Allow-Origin"), "https://app.example.com")
+	eq(t, "Vary", header.Get("Vary"), "Origin")
+	_, header = serve("GET", "https://evil.com", "")
+	eq(t, "Allow-Origin", header.Get("Access-Control-Allow-Origin"), "")
+	eq(t, "Vary", header.Get("Vary"), "Origin")
+
+	// Credentials are not allowed with any origin.
+	defer setConfig(map[string]string{"cors.origins": "*", "cors.credentials": "true"})()
+	_, header = serve("GET", "https://evil.com", "")
+	eq(t, "Allow-Origin", header.Get("Access-Control-Allow-Origin"), "*")
+	eq(t, "Allow-Credentials", header.Get("Access-Control-Allow-Credentials"), "")
+	eq(t, "Vary", header.Get("Vary"), "")
+}
+
+func TestRoutedPreflight(t *testing.T) {
+	oldFilters := Filters
+	defer func() {
+		Filters = oldFilters
+		filterOverrides = make(map[string][]Filter)
+	}()
+	Filters = []Filter{RouterFilter, FilterConfiguringFilter, CORSFilter, NilFilter}
+	startFakeBookingApp()
+	defer setConfig(map[string]string{"cors.origins": "*"})()
+
+	preflight := func() http.Header {
+		c, resp := testController(testRequest("OPTIONS", "/hotels/3/booking",
+			"Origin", "https://app.example.com", "Access-Control-Request-Method", "GET"))
+		Filters[0](c, Filters[1:])
+		return resp.Header()
+	}
+
+	eq(t, "Allow-Origin", preflight().Get("Access-Control-Allow-Origin"), "*")
+
+	// Without the filter, the preflight is answered like any OPTIONS request.
+	FilterController(Hotels{}).Remove(CORSFilter)
+	header := preflight()
+	eq(t, "Allow-Origin", header.Get("Access-Control-Allow-Origin"), "")
+	eq(t, "Allow", header.Get("Allow"), "GET, HEAD, POST, OPTIONS")
+}
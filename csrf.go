@@ -0,0 +1,111 @@
+package revel
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"html/template"
+	"net/url"
+	"strings"
+)
+
+const (
+	CSRF_TOKEN_KEY = "_CSRF"        // The session key holding the token.
+	CSRF_FIELD     = "csrf_token"   // The form field carrying the token.
+	CSRF_HEADER    = "X-CSRF-Token" // The header carrying the token (e.g. for Ajax requests).
+
+	// The render arg holding the token, for the csrf_token and csrfField funcs.
+	csrfRenderArg = "_csrfToken"
+)
+
+// Methods that do not change state, and so do not require a token.
+var csrfSafeMethods = map[string]bool{
+	"GET":     true,
+	"HEAD":    true,
+	"OPTIONS": true,
+	"TRACE":   true,
+}
+
+func init() {
+	TemplateFuncs["csrf_token"] = func(renderArgs map[string]interface{}) string {
+		token, ok := renderArgs[csrfRenderArg].(string)
+		if !ok {
+			WARN.Println("Called 'csrf_token' without the CSRFFilter.")
+		}
+		return token
+	}
+	TemplateFuncs["csrfField"] = func(renderArgs map[string]interface{}) template.HTML {
+		token, ok := renderArgs[csrfRenderArg].(string)
+		if !ok {
+			WARN.Println("Called 'csrfField' without the CSRFFilter.")
+			return template.HTML("")
+		}
+		return template.HTML(fmt.Sprintf(`<input type="hidden" name="%s" value="%s">`,
+			CSRF_FIELD, html.EscapeString(token)))
+	}
+}
+
+// CSRFFilter protects against cross-site request forgery.  Each session is
+// given a random token, which must accompany any request with an unsafe method
+// (e.g. POST), either in the csrf_token form field or the X-CSRF-Token header.
+// Requests without it are rejected with 403 Forbidden.  Websocket requests are
+// instead rejected if their Origin is another host.
+//
+// It must come after the ParamsFilter and SessionFilter.  Forms include the
+// token with {{csrfField .}}, and scripts may read it with {{csrf_token .}}.
+//
+// Actions that must accept requests from elsewhere (e.g. webhooks) may opt out:
+//   revel.FilterAction(App.Webhook).
+//     Remove(revel.CSRFFilter)
+func CSRFFilter(c *Controller, fc []Filter) {
+	token, ok := c.Session[CSRF_TOKEN_KEY]
+	if !ok {
+		token = newCsrfToken()
+		c.Session[CSRF_TOKEN_KEY] = token
+	}
+	c.RenderArgs[csrfRenderArg] = token
+
+	switch {
+	case c.Request.Method == "WS":
+		// Websockets can not carry a token, but browsers send their origin.
+		if !csrfSameOrigin(c.Request) {
+			INFO.Printf("Rejected websocket %s: cross-origin request from %s", c.Request.URL.Path, c.Request.Header.Get("Origin"))
+			c.Result = c.Forbidden("Cross-origin websocket request")
+			return
+		}
+	case !csrfSafeMethods[c.Request.Method]:
+		sent := c.Request.Header.Get(CSRF_HEADER)
+		if sent == "" {
+			sent = c.Params.Form.Get(CSRF_FIELD)
+		}
+		if subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+			INFO.Printf("Rejected %s %s: missing or invalid CSRF token", c.Request.Method, c.Request.URL.Path)
+			c.Result = c.Forbidden("Missing or invalid CSRF token")
+			return
+		}
+	}
+
+	fc[0](c, fc[1:])
+}
+
+// csrfSameOrigin returns true if the request's Origin header, if it has one,
+// names the host that the request was sent to.
+func csrfSameOrigin(req *Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, req.Host)
+}
+
+// newCsrfToken returns a new random token.
+func newCsrfToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,85 @@
+package revel
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestCSRFFilter(t *testing.T) {
+	startFakeBookingApp()
+	serve := func(method, header string, form url.Values, session Session) *Controller {
+		c, _ := testController(testRequest(method, "/hotels", CSRF_HEADER, header))
+		c.Session = session
+		c.Params.Form = form
+		CSRFFilter(c, NilChain)
+		return c
+	}
+
+	// A safe request is given a token.
+	session := make(Session)
+	c := serve("GET", "", nil, session)
+	token := session[CSRF_TOKEN_KEY]
+	if len(token) != 64 || c.Result != nil {
+		t.Fatalf("Expected a token and no result, got %q, %v", token, c.Result)
+	}
+	serve("GET", "", nil, session)
+	eq(t, "Token kept", session[CSRF_TOKEN_KEY], token)
+
+	// Unsafe requests must carry it.
+	for _, test := range []struct {
+		method, header, field string
+		allowed               bool
+	}{
+		{"POST", "", "", false},
+		{"POST", "", "wrong", false},
+		{"POST", "", token, true},
+		{"PUT", token, "", true},
+		{"DELETE", "wrong", token, false},
+	} {
+		c := serve(test.method, test.header, url.Values{CSRF_FIELD: {test.field}}, session)
+		if allowed := c.Result == nil; allowed != test.allowed {
+			t.Errorf("%s (header %q, field %q): expected allowed = %v", test.method, test.header, test.field, test.allowed)
+		}
+		if !test.allowed {
+			eq(t, "Status", c.Response.Status, http.StatusForbidden)
+		}
+	}
+
+	// Websockets are allowed from the same origin.
+	for _, test := range []struct {
+		origin  string
+		allowed bool
+	}{
+		{"", true},
+		{"http://www.example.com", true},
+		{"https://WWW.example.com", true},
+		{"http://evil.example.com", false},
+		{"null", false},
+	} {
+		c, _ := testController(testRequest("GET", "http://www.example.com/hotels/live", "Origin", test.origin))
+		c.Request.Method = "WS"
+		c.Session = session
+		CSRFFilter(c, NilChain)
+		if allowed := c.Result == nil; allowed != test.allowed {
+			t.Errorf("Websocket from %q: expected allowed = %v", test.origin, test.allowed)
+		}
+	}
+
+	// A new session has no token to match.
+	if c := serve("POST", "", url.Values{CSRF_FIELD: {token}}, make(Session)); c.Result == nil {
+		t.Error("Expected a token from another session to be rejected")
+	}
+
+	// The template funcs render the token.
+	tmpl := template.Must(template.New("").Funcs(TemplateFuncs).Parse(`{{csrf_token .}}|{{csrfField .}}`))
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, c.RenderArgs); err != nil {
+		t.Fatal(err)
+	}
+	if expected := token + `|<input type="hidden" name="csrf_token" value="` + token + `">`; out.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, out.String())
+	}
+}
@@ -27,10 +27,10 @@ type sourceLine struct {
 // that, on the line that eventually triggered the panic.  Returns nil if no
 // relevant stack frame can be found.
 func NewErrorFromPanic(err interface{}) *Error {
+	err, stack := panicStack(err)
 
 	// Parse the filename and line from the originating line of app code.
 	// /Users/robfig/code/gocode/src/revel/samples/booking/app/controllers/hotels.go:191 (0x44735)
-	stack := string(debug.Stack())
 	frame, basePath := findRelevantStackFrame(stack)
 	if frame == -1 {
 		return nil
@@ -115,3 +115,19 @@ func findRelevantStackFrame(stack string) (int, string) {
 	}
 	return -1, ""
 }
+
+// A goroutinePanic is a panic recovered in another goroutine (e.g. that of the
+// TimeoutFilter), raised again along with the stack of that goroutine.
+type goroutinePanic struct {
+	err   interface{}
+	stack string
+}
+
+// panicStack returns the value passed to panic, and the stack of the goroutine
+// that panicked.  It must be called while recovering.
+func panicStack(err interface{}) (interface{}, string) {
+	if p, ok := err.(*goroutinePanic); ok {
+		return p.err, p.stack
+	}
+	return err, string(debug.Stack())
+}
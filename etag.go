@@ -0,0 +1,101 @@
+package revel
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SetETag sets the entity tag identifying the version of the response, so that
+// a client with a copy of it is sent 304 Not Modified.  A weak tag says that
+// the response is equivalent, rather than identical, to others with the tag.
+//
+// An action that sets a validator (or a Last-Modified time) may be answered
+// without rendering its result.  Otherwise, a strong ETag is computed from the
+// rendered JSON, XML or template.  This is configured by results.etag in
+// app.conf: "strong" (the default), "weak" or "off".  The CompressFilter makes
+// strong ETags weak, as they would otherwise match a differently encoded body.
+func (resp *Response) SetETag(tag string, weak bool) {
+	etag := `"` + strings.Replace(tag, `"`, "", -1) + `"`
+	if weak {
+		etag = "W/" + etag
+	}
+	resp.Out.Header().Set("ETag", etag)
+}
+
+// SetLastModified sets the time at which the content of the response last
+// changed, so that a client with a copy since then is sent 304 Not Modified.
+func (resp *Response) SetLastModified(modTime time.Time) {
+	resp.Out.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+}
+
+// notModified answers the request with 304 Not Modified, and returns true, if
+// the client's copy of the response is current.  If the body is given, and the
+// response has no ETag, one is computed from it.
+func notModified(req *Request, resp *Response, body []byte) bool {
+	if req.Method != "GET" && req.Method != "HEAD" {
+		return false
+	}
+	if resp.Status != 0 && resp.Status != http.StatusOK {
+		return false
+	}
+
+	header := resp.Out.Header()
+	if body != nil && header.Get("ETag") == "" {
+		switch Config.StringDefault("results.etag", "strong") {
+		case "strong":
+			header.Set("ETag", bodyETag(body))
+		case "weak":
+			header.Set("ETag", "W/"+bodyETag(body))
+		}
+	}
+	if !clientIsCurrent(req.Request, header) {
+		return false
+	}
+
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+	resp.Status = http.StatusNotModified
+	resp.Out.WriteHeader(resp.Status)
+	return true
+}
+
+func bodyETag(body []byte) string {
+	sum := sha1.Sum(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// clientIsCurrent returns true if the request's If-None-Match or (failing
+// that) If-Modified-Since header matches the response's validators.
+func clientIsCurrent(r *http.Request, header http.Header) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		etag := header.Get("ETag")
+		return etag != "" && etagMatches(match, etag)
+	}
+	if since := r.Header.Get("If-Modified-Since"); since != "" {
+		sinceTime, err := http.ParseTime(since)
+		if err != nil {
+			return false
+		}
+		modTime, err := http.ParseTime(header.Get("Last-Modified"))
+		return err == nil && !modTime.After(sinceTime)
+	}
+	return false
+}
+
+// etagMatches returns true if the list of tags from If-None-Match includes the
+// given tag.  As the header is only used for GET and HEAD, weak tags match.
+func etagMatches(list, etag string) bool {
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(list, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
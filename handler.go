@@ -0,0 +1,79 @@
+package revel
+
+import (
+	"net/http"
+	"strings"
+)
+
+// mountedHandler is an http.Handler mounted at a path by Handle.
+type mountedHandler struct {
+	path    string // e.g. "/debug/pprof/"
+	handler http.Handler
+	filters []Filter // The global filters that apply to the handler.
+}
+
+var mountedHandlers []*mountedHandler
+
+// Handle mounts an http.Handler at the given path.  As with http.ServeMux, a
+// path ending in a slash matches every path beneath it, and otherwise the path
+// must match exactly.  Mounted handlers take precedence over the routes file.
+//
+// Requests for the handler skip the controller and action.  They pass through
+// the filters preceding RouterFilter, and then through those of the remaining
+// global Filters that are given here, in their usual order.  For example:
+//   revel.Handle("/debug/pprof/", http.HandlerFunc(pprof.Index), revel.CompressFilter)
+//
+// Filters that require an action (e.g. InterceptorFilter or ActionInvoker)
+// must not be given.
+func Handle(path string, handler http.Handler, filters ...Filter) {
+	if !strings.HasPrefix(path, "/") {
+		panic("revel: handler path must begin with /: " + path)
+	}
+	for _, h := range mountedHandlers {
+		if h.path == path {
+			panic("revel: multiple handlers mounted at " + path)
+		}
+	}
+	mountedHandlers = append(mountedHandlers, &mountedHandler{path, handler, filters})
+}
+
+// findHandler returns the handler mounted at the longest path matching the
+// given request path, or nil if there is none.
+func findHandler(path string) *mountedHandler {
+	var found *mountedHandler
+	for _, h := range mountedHandlers {
+		if h.path != path && !(strings.HasSuffix(h.path, "/") && strings.HasPrefix(path, h.path)) {
+			continue
+		}
+		if found == nil || len(h.path) > len(found.path) {
+			found = h
+		}
+	}
+	return found
+}
+
+// serve runs the selected filters from the given chain, followed by the handler.
+func (h *mountedHandler) serve(c *Controller, fc []Filter) {
+	var chain []Filter
+	for _, f := range fc {
+		for _, selected := range h.filters {
+			if FilterEq(f, selected) {
+				chain = append(chain, f)
+				break
+			}
+		}
+	}
+	chain = append(chain, func(c *Controller, _ []Filter) {
+		c.Result = &handlerResult{h.handler}
+	})
+	chain[0](c, chain[1:])
+}
+
+// handlerResult serves the request with a mounted handler.
+type handlerResult struct {
+	handler http.Handler
+}
+
+func (r *handlerResult) Apply(req *Request, resp *Response) {
+	r.handler.ServeHTTP(resp.Out, req.Request)
+}
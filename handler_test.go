@@ -0,0 +1,62 @@
+package revel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFindHandler(t *testing.T) {
+	defer func() { mountedHandlers = nil }()
+	Handle("/debug/pprof/", http.NotFoundHandler())
+	Handle("/debug/pprof/heap", http.NotFoundHandler())
+	Handle("/metrics", http.NotFoundHandler())
+
+	for path, expected := range map[string]string{
+		"/debug/pprof/":        "/debug/pprof/",
+		"/debug/pprof/profile": "/debug/pprof/",
+		"/debug/pprof/heap":    "/debug/pprof/heap",
+		"/metrics":             "/metrics",
+		"/metrics/extra":       "",
+		"/debug/pprof":         "",
+		"/hotels":              "",
+	} {
+		var actual string
+		if h := findHandler(path); h != nil {
+			actual = h.path
+		}
+		eq(t, "Handler for "+path, actual, expected)
+	}
+}
+
+func TestHandlerFilters(t *testing.T) {
+	defer func() { mountedHandlers = nil }()
+
+	var ran []string
+	var (
+		selectedFilter = func(c *Controller, fc []Filter) {
+			ran = append(ran, "selected")
+			fc[0](c, fc[1:])
+		}
+		otherFilter = func(c *Controller, fc []Filter) {
+			ran = append(ran, "other")
+			fc[0](c, fc[1:])
+		}
+	)
+	Handle("/legacy/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("legacy " + r.URL.Path))
+	}), selectedFilter)
+
+	resp := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/legacy/page", nil)
+	c := NewController(NewRequest(req), NewResponse(resp))
+	RouterFilter(c, []Filter{otherFilter, selectedFilter, otherFilter, ActionInvoker})
+
+	eq(t, "Filters run", strings.Join(ran, ", "), "selected")
+	if c.Result == nil {
+		t.Fatal("Expected a result for the mounted handler")
+	}
+	c.Result.Apply(c.Request, c.Response)
+	eq(t, "Body", resp.Body.String(), "legacy /legacy/page")
+}
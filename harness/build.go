@@ -315,7 +315,16 @@ func (_ t{{$c.StructName}}) {{.Name}}({{range .Args}}
 	args := make(map[string]string)
 	{{range .Args}}
 	revel.Unbind(args, "{{.Name}}", {{.Name}}){{end}}
-	return revel.MainRouter.Reverse("{{$c.StructName}}.{{.Name}}", args).Url
+	values := make(map[string][]string, len(args))
+	for k, v := range args {
+		values[k] = []string{v}
+	}
+	actionDef, err := revel.MainRouter.ReverseValues("{{$c.StructName}}.{{.Name}}", values)
+	if err != nil {
+		revel.ERROR.Println(err)
+		return ""
+	}
+	return actionDef.Url
 }
 {{end}}
 {{end}}
@@ -0,0 +1,147 @@
+// Health checks for liveness and readiness probes.
+//
+// Packages register checks, e.g.
+//
+//    health.Register("db", func(ctx context.Context) error {
+//        return db.Db.PingContext(ctx)
+//    })
+//
+// The health module answers /@health whenever the app is serving, and /@ready
+// with the status of the registered checks.  To enable it, add it to app.conf
+// and import its routes:
+//
+//    module.health = github.com/robfig/revel/modules/health
+//    module:health
+//
+// A check that fails (or does not finish within health.timeout, by default 5s)
+// is reported.  The app is not ready if a critical check fails.  Whether a check
+// is critical may be overridden in app.conf, e.g. "health.cache.critical = true".
+package health
+
+import (
+	"context"
+	"fmt"
+	"github.com/robfig/revel"
+	"sync"
+	"time"
+)
+
+const DEFAULT_TIMEOUT = 5 * time.Second
+
+// A Check returns an error if the thing it checks is unhealthy.  It should
+// return once the context is done.
+type Check func(ctx context.Context) error
+
+type check struct {
+	name     string
+	fn       Check
+	critical bool
+}
+
+var (
+	checksLock sync.Mutex
+	checks     []*check
+)
+
+// Register adds a check which must pass for the app to be ready.
+// A check registered under the same name is replaced.
+func Register(name string, fn Check) {
+	register(&check{name, fn, true})
+}
+
+// RegisterNonCritical adds a check which is reported, but which does not stop
+// the app from being ready if it fails.
+func RegisterNonCritical(name string, fn Check) {
+	register(&check{name, fn, false})
+}
+
+func register(c *check) {
+	checksLock.Lock()
+	defer checksLock.Unlock()
+	for i, existing := range checks {
+		if existing.name == c.name {
+			checks[i] = c
+			return
+		}
+	}
+	checks = append(checks, c)
+}
+
+// Report is the status of the app, as rendered by /@ready.
+type Report struct {
+	Status string                  `json:"status"` // "ok" or "fail"
+	Checks map[string]*CheckResult `json:"checks"`
+}
+
+// CheckResult is the outcome of a single check.
+type CheckResult struct {
+	Status   string `json:"status"` // "ok" or "fail"
+	Critical bool   `json:"critical"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Ready runs the registered checks concurrently and reports their results.
+// The report fails if any critical check fails.
+func Ready(ctx context.Context) *Report {
+	checksLock.Lock()
+	current := make([]*check, len(checks))
+	copy(current, checks)
+	checksLock.Unlock()
+
+	timeout := revel.Config.DurationDefault("health.timeout", DEFAULT_TIMEOUT)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var (
+		results = make([]*CheckResult, len(current))
+		wg      sync.WaitGroup
+	)
+	for i, c := range current {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := &Report{Status: "ok", Checks: make(map[string]*CheckResult, len(current))}
+	for i, c := range current {
+		results[i].Critical = revel.Config.BoolDefault("health."+c.name+".critical", c.critical)
+		if results[i].Status != "ok" && results[i].Critical {
+			report.Status = "fail"
+		}
+		report.Checks[c.name] = results[i]
+	}
+	return report
+}
+
+// run runs the check, giving up on it when the context is done.  A panic in the
+// check counts as a failure.
+func run(ctx context.Context, c *check) *CheckResult {
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				done <- fmt.Errorf("panic: %v", err)
+			}
+		}()
+		done <- c.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("did not finish: %s", ctx.Err())
+	}
+
+	result := &CheckResult{Status: "ok", Duration: time.Since(start).String()}
+	if err != nil {
+		result.Status, result.Error = "fail", err.Error()
+		revel.WARN.Printf("Health check %s failed: %s", c.name, err)
+	}
+	return result
+}
@@ -0,0 +1,139 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/robfig/revel"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// setConfig loads an app.conf with the given content as revel.Config.
+func setConfig(t *testing.T, content string) {
+	dir, err := ioutil.TempDir("", "revel-health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = ioutil.WriteFile(filepath.Join(dir, "app.conf"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(paths []string) { revel.ConfPaths = paths }(revel.ConfPaths)
+	revel.ConfPaths = []string{dir}
+	if revel.Config, err = revel.LoadConfig("app.conf"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func pass(context.Context) error { return nil }
+func fail(context.Context) error { return errors.New("down") }
+
+func TestReady(t *testing.T) {
+	defer func(registered []*check) { checks = registered }(checks)
+	defer func(config *revel.MergedConfig) { revel.Config = config }(revel.Config)
+
+	for _, test := range []struct {
+		name     string
+		conf     string
+		critical []*check
+		other    []*check
+		status   string
+		errors   map[string]string
+	}{
+		{
+			name:   "no checks",
+			status: "ok",
+		},
+		{
+			name:     "all pass",
+			critical: []*check{{"db", pass, true}},
+			other:    []*check{{"cache", pass, false}},
+			status:   "ok",
+		},
+		{
+			name:     "critical fails",
+			critical: []*check{{"db", fail, true}},
+			other:    []*check{{"cache", pass, false}},
+			status:   "fail",
+			errors:   map[string]string{"db": "down"},
+		},
+		{
+			name:     "non-critical fails",
+			critical: []*check{{"db", pass, true}},
+			other:    []*check{{"cache", fail, false}},
+			status:   "ok",
+			errors:   map[string]string{"cache": "down"},
+		},
+		{
+			name:   "made critical in app.conf",
+			conf:   "health.cache.critical = true\n",
+			other:  []*check{{"cache", fail, false}},
+			status: "fail",
+			errors: map[string]string{"cache": "down"},
+		},
+		{
+			name:     "made non-critical in app.conf",
+			conf:     "health.db.critical = false\n",
+			critical: []*check{{"db", fail, true}},
+			status:   "ok",
+			errors:   map[string]string{"db": "down"},
+		},
+		{
+			name: "timed out",
+			conf: "health.timeout = 10ms\n",
+			critical: []*check{{"slow", func(context.Context) error {
+				time.Sleep(time.Second)
+				return nil
+			}, true}},
+			status: "fail",
+			errors: map[string]string{"slow": "did not finish: context deadline exceeded"},
+		},
+		{
+			name: "panicked",
+			critical: []*check{{"broken", func(context.Context) error {
+				panic("boom")
+			}, true}},
+			status: "fail",
+			errors: map[string]string{"broken": "panic: boom"},
+		},
+	} {
+		setConfig(t, test.conf)
+		checks = nil
+		for _, c := range test.critical {
+			Register(c.name, c.fn)
+		}
+		for _, c := range test.other {
+			RegisterNonCritical(c.name, c.fn)
+		}
+
+		report := Ready(context.Background())
+		if report.Status != test.status {
+			t.Errorf("%s: expected status %s, got %s", test.name, test.status, report.Status)
+		}
+		if len(report.Checks) != len(test.critical)+len(test.other) {
+			t.Errorf("%s: expected a result for each check, got %v", test.name, report.Checks)
+		}
+		for name, result := range report.Checks {
+			expected, failed := test.errors[name]
+			if result.Error != expected || (result.Status == "fail") != failed {
+				t.Errorf("%s: check %s: expected error %q, got %s %q", test.name, name, expected, result.Status, result.Error)
+			}
+		}
+	}
+}
+
+// Test that a check registered twice is replaced.
+func TestRegisterReplaces(t *testing.T) {
+	defer func(registered []*check) { checks = registered }(checks)
+	defer func(config *revel.MergedConfig) { revel.Config = config }(revel.Config)
+	setConfig(t, "")
+	checks = nil
+	Register("db", fail)
+	Register("db", pass)
+	if report := Ready(context.Background()); report.Status != "ok" || len(report.Checks) != 1 {
+		t.Errorf("Expected the replacement check to pass, got %s %v", report.Status, report.Checks)
+	}
+}
@@ -3,6 +3,7 @@ package revel
 import (
 	"bytes"
 	"code.google.com/p/go.net/websocket"
+	"crypto/x509"
 	"fmt"
 	"net/http"
 	"sort"
@@ -17,6 +18,10 @@ type Request struct {
 	AcceptLanguages AcceptLanguages
 	Locale          string
 	Websocket       *websocket.Conn
+
+	// The client certificate, if one was presented and verified against the
+	// CA bundle configured by http.sslclientca.
+	ClientCertificate *x509.Certificate
 }
 
 type Response struct {
@@ -31,12 +36,16 @@ func NewResponse(w http.ResponseWriter) *Response {
 }
 
 func NewRequest(r *http.Request) *Request {
-	return &Request{
+	req := &Request{
 		Request:         r,
 		ContentType:     ResolveContentType(r),
 		Format:          ResolveFormat(r),
 		AcceptLanguages: ResolveAcceptLanguage(r),
 	}
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		req.ClientCertificate = r.TLS.VerifiedChains[0][0]
+	}
+	return req
 }
 
 // Write the header (for now, just the status code).
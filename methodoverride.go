@@ -0,0 +1,88 @@
+package revel
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+)
+
+const (
+	METHOD_OVERRIDE_FIELD  = "_method"                // The form field naming the method.
+	METHOD_OVERRIDE_HEADER = "X-HTTP-Method-Override" // The header naming the method.
+)
+
+// How much of a form body is read to look for the method field.  The field
+// should come before any large values, as it does using methodField.
+const maxMethodOverrideRead = 64 << 10
+
+// The methods that a POST may be changed to, from http.methodoverride.
+var methodOverrides []string
+
+func init() {
+	OnAppStart(func() {
+		methodOverrides = splitList(strings.ToUpper(Config.StringDefault("http.methodoverride", "PUT, PATCH, DELETE")))
+	})
+
+	// Emits the hidden field giving a form's method, e.g.
+	//   <form action="{{url "Hotels.Update" .hotel.Id}}" method="POST">
+	//     {{methodField "PUT"}}
+	TemplateFuncs["methodField"] = func(method string) template.HTML {
+		return template.HTML(fmt.Sprintf(`<input type="hidden" name="%s" value="%s">`,
+			METHOD_OVERRIDE_FIELD, html.EscapeString(strings.ToUpper(method))))
+	}
+}
+
+// MethodOverrideFilter allows HTML forms, which can only GET or POST, to reach
+// routes for other methods.  The method of a POST is changed to that given in
+// the X-HTTP-Method-Override header, or else in the _method field of a
+// url-encoded form.  Only the methods configured in app.conf are allowed:
+//   http.methodoverride = PUT, PATCH, DELETE
+//
+// It must come before the RouterFilter.  The body is left in place, to be
+// parsed by the ParamsFilter.
+func MethodOverrideFilter(c *Controller, fc []Filter) {
+	if c.Request.Method == "POST" {
+		method := c.Request.Header.Get(METHOD_OVERRIDE_HEADER)
+		if method == "" && c.Request.ContentType == "application/x-www-form-urlencoded" {
+			method = methodFromForm(c.Request)
+		}
+		method = strings.ToUpper(strings.TrimSpace(method))
+		if method != "" && ContainsString(methodOverrides, method) {
+			c.Request.Method = method
+		}
+	}
+
+	fc[0](c, fc[1:])
+}
+
+// methodFromForm returns the value of the method field in the request's form,
+// restoring the body that it reads.
+func methodFromForm(req *Request) string {
+	if req.Body == nil {
+		return ""
+	}
+	head, err := ioutil.ReadAll(io.LimitReader(req.Body, maxMethodOverrideRead))
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), req.Body), req.Body}
+	if err != nil {
+		return ""
+	}
+
+	// If the body is longer, only look at the fields read in full.
+	if len(head) == maxMethodOverrideRead {
+		amp := bytes.LastIndexByte(head, '&')
+		if amp == -1 {
+			return ""
+		}
+		head = head[:amp]
+	}
+	values, _ := url.ParseQuery(string(head))
+	return values.Get(METHOD_OVERRIDE_FIELD)
+}
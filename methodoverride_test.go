@@ -0,0 +1,63 @@
+package revel
+
+import (
+	"bytes"
+	"html/template"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestMethodOverrideFilter(t *testing.T) {
+	startFakeBookingApp()
+	serve := func(method, header, body string) *Controller {
+		req := testRequest(method, "/hotels/3",
+			"Content-Type", "application/x-www-form-urlencoded", METHOD_OVERRIDE_HEADER, header)
+		req.Body = ioutil.NopCloser(strings.NewReader(body))
+		c, _ := testController(req)
+		MethodOverrideFilter(c, NilChain)
+		return c
+	}
+
+	for _, test := range []struct {
+		method, header, body, expected string
+	}{
+		{"POST", "", "_method=put&name=x", "PUT"},
+		{"POST", "", "name=x&_method=delete", "DELETE"},
+		{"POST", "PATCH", "name=x", "PATCH"},
+		{"POST", "PATCH", "_method=DELETE", "PATCH"},
+		{"POST", "", "_method=TRACE", "POST"},
+		{"POST", "CONNECT", "", "POST"},
+		{"POST", "", "name=x", "POST"},
+		{"GET", "DELETE", "_method=DELETE", "GET"},
+	} {
+		c := serve(test.method, test.header, test.body)
+		eq(t, test.method+" "+test.header+" "+test.body, c.Request.Method, test.expected)
+	}
+
+	// The form is still there to be parsed, whatever the method.
+	for _, method := range []string{"PUT", "DELETE"} {
+		c := serve("POST", "", "_method="+method+"&csrf_token=abc&name=x")
+		eq(t, "Method", c.Request.Method, method)
+		ParseParams(c.Params, c.Request)
+		eq(t, method+" form field", c.Params.Form.Get("name"), "x")
+		eq(t, method+" CSRF token", c.Params.Form.Get(CSRF_FIELD), "abc")
+		eq(t, "Method after parsing", c.Request.Method, method)
+	}
+
+	// Of a long form, only the fields read in full are considered.
+	long := "_method=PUT&value=" + strings.Repeat("x", maxMethodOverrideRead)
+	c := serve("POST", "", long)
+	eq(t, "Long form", c.Request.Method, "PUT")
+	ParseParams(c.Params, c.Request)
+	eq(t, "Long form field", len(c.Params.Get("value")), maxMethodOverrideRead)
+	eq(t, "Truncated field", serve("POST", "", long[len("_method=PUT&"):]+"&_method=PUT").Request.Method, "POST")
+
+	// The template func emits the field.
+	tmpl := template.Must(template.New("").Funcs(TemplateFuncs).Parse(`{{methodField "put"}}`))
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, nil); err != nil {
+		t.Fatal(err)
+	}
+	eq(t, "Field", out.String(), `<input type="hidden" name="_method" value="PUT">`)
+}
@@ -10,8 +10,10 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"github.com/robfig/revel"
+	"github.com/robfig/revel/health"
 )
 
 var (
@@ -20,6 +22,17 @@ var (
 	Spec   string
 )
 
+func init() {
+	revel.OnAppStop(func() {
+		if Db == nil {
+			return
+		}
+		if err := Db.Close(); err != nil {
+			revel.ERROR.Println("Failed to close the database:", err)
+		}
+	})
+}
+
 func Init() {
 	// Read configuration.
 	var found bool
@@ -36,6 +49,11 @@ func Init() {
 	if err != nil {
 		revel.ERROR.Fatal(err)
 	}
+
+	// The app is not ready unless the database is reachable.
+	health.Register("db", func(ctx context.Context) error {
+		return Db.PingContext(ctx)
+	})
 }
 
 type Transactional struct {
@@ -43,9 +61,10 @@ type Transactional struct {
 	Txn *sql.Tx
 }
 
-// Begin a transaction
+// Begin a transaction, bound to the request's context.  The transaction is
+// rolled back if the context is cancelled, e.g. when the client disconnects.
 func (c *Transactional) Begin() revel.Result {
-	txn, err := Db.Begin()
+	txn, err := Db.BeginTx(c.Request.Context(), nil)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"github.com/robfig/revel"
+	"github.com/robfig/revel/health"
+	"net/http"
+)
+
+type Health struct {
+	*revel.Controller
+}
+
+// Live reports that the app is serving requests.
+func (c Health) Live() revel.Result {
+	return c.RenderJson(map[string]string{"status": "ok"})
+}
+
+// Ready reports the status of the registered checks, with a 503 if any
+// critical check failed.
+func (c Health) Ready() revel.Result {
+	report := health.Ready(c.Request.Context())
+	if report.Status != "ok" {
+		c.Response.Status = http.StatusServiceUnavailable
+	}
+	return c.RenderJson(report)
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/robfig/revel"
+	"github.com/robfig/revel/health"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReady(t *testing.T) {
+	dir, err := ioutil.TempDir("", "revel-health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = ioutil.WriteFile(filepath.Join(dir, "app.conf"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(paths []string, config *revel.MergedConfig) {
+		revel.ConfPaths, revel.Config = paths, config
+	}(revel.ConfPaths, revel.Config)
+	revel.ConfPaths = []string{dir}
+	if revel.Config, err = revel.LoadConfig("app.conf"); err != nil {
+		t.Fatal(err)
+	}
+
+	ready := func() (int, *health.Report) {
+		req, _ := http.NewRequest("GET", "/@ready", nil)
+		resp := httptest.NewRecorder()
+		c := Health{revel.NewController(revel.NewRequest(req), revel.NewResponse(resp))}
+		c.Ready().Apply(c.Request, c.Response)
+		var report health.Report
+		if err := json.Unmarshal(resp.Body.Bytes(), &report); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Code, &report
+	}
+
+	health.Register("test", func(context.Context) error { return nil })
+	if status, report := ready(); status != http.StatusOK || report.Status != "ok" {
+		t.Errorf("Expected 200 ok, got %d %s", status, report.Status)
+	}
+
+	health.Register("test", func(context.Context) error { return errors.New("down") })
+	status, report := ready()
+	if status != http.StatusServiceUnavailable || report.Status != "fail" {
+		t.Errorf("Expected 503 fail, got %d %s", status, report.Status)
+	}
+	if result := report.Checks["test"]; result == nil || result.Error != "down" {
+		t.Errorf("Expected the check's error, got %#v", result)
+	}
+}
@@ -18,6 +18,16 @@ type Job struct {
 
 const UNNAMED = "(unnamed)"
 
+var (
+	// runningJobs counts the jobs in progress, so that shutdown can wait for them.
+	runningJobs sync.WaitGroup
+
+	// The number of runs in progress of each job, to report those abandoned at
+	// shutdown.
+	runningLock  sync.Mutex
+	runningCount = make(map[*Job]int)
+)
+
 func New(job cron.Job) *Job {
 	name := reflect.TypeOf(job).Name()
 	if name == "Func" {
@@ -37,6 +47,13 @@ func (j *Job) Status() string {
 }
 
 func (j *Job) Run() {
+	runningJobs.Add(1)
+	countRun(j, 1)
+	defer func() {
+		countRun(j, -1)
+		runningJobs.Done()
+	}()
+
 	// If the job panics, just print a stack trace.
 	// Don't let the whole process die.
 	defer func() {
@@ -64,3 +81,22 @@ func (j *Job) Run() {
 
 	j.inner.Run()
 }
+
+func countRun(j *Job, delta int) {
+	runningLock.Lock()
+	defer runningLock.Unlock()
+	if runningCount[j] += delta; runningCount[j] == 0 {
+		delete(runningCount, j)
+	}
+}
+
+// runningJobNames returns the names of the jobs in progress.
+func runningJobNames() []string {
+	runningLock.Lock()
+	defer runningLock.Unlock()
+	var names []string
+	for j := range runningCount {
+		names = append(names, j.Name)
+	}
+	return names
+}
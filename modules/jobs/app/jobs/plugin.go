@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"github.com/robfig/cron"
 	"github.com/robfig/revel"
+	"strings"
+	"time"
 )
 
 const DEFAULT_JOB_POOL_SIZE = 10
@@ -29,4 +31,30 @@ func init() {
 		MainCron.Start()
 		fmt.Println("Go to /@jobs to see job status.")
 	})
+	revel.OnAppStop(func() {
+		// Stop scheduling jobs, and wait for those running to finish, for as long
+		// as the server waits for requests.
+		MainCron.Stop()
+		timeout := revel.Config.DurationDefault("http.timeout.shutdown", 10*time.Second)
+		if !waitForJobs(timeout) {
+			revel.ERROR.Printf("Jobs still running after %s, abandoning them: %s",
+				timeout, strings.Join(runningJobNames(), ", "))
+		}
+	})
+}
+
+// waitForJobs waits for the running jobs to finish, returning false if they
+// have not by the end of the timeout.
+func waitForJobs(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		runningJobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
 }
@@ -1,9 +1,5 @@
 package revel
 
-import (
-	"runtime/debug"
-)
-
 // PanicFilter wraps the action invocation in a protective defer blanket that
 // converts panics into 500 error pages.
 func PanicFilter(c *Controller, fc []Filter) {
@@ -20,12 +16,13 @@ func PanicFilter(c *Controller, fc []Filter) {
 func handleInvocationPanic(c *Controller, err interface{}) {
 	error := NewErrorFromPanic(err)
 	if error == nil {
-		ERROR.Print(err, "\n", string(debug.Stack()))
+		err, stack := panicStack(err)
+		ERROR.Print("Request ", c.RequestId, ": ", err, "\n", stack)
 		c.Response.Out.WriteHeader(500)
-		c.Response.Out.Write(debug.Stack())
+		c.Response.Out.Write([]byte(stack))
 		return
 	}
 
-	ERROR.Print(err, "\n", error.Stack)
+	ERROR.Print("Request ", c.RequestId, ": ", err, "\n", error.Stack)
 	c.Result = c.RenderError(error)
 }
@@ -1,10 +1,15 @@
 package revel
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"mime/multipart"
+	"net/http"
 	"net/url"
 	"os"
 	"reflect"
+	"strconv"
 )
 
 // Params provides a unified view of the request params.
@@ -29,14 +34,85 @@ type Params struct {
 	tmpFiles []*os.File                         // Temp files used during the request.
 }
 
+var (
+	// The maximum size of a request body in bytes, configured by
+	// http.maxrequestsize in app.conf.  Limits for a controller or action are
+	// keyed by their lower-cased name, e.g. "hotels" or "hotels.upload", and the
+	// global limit by "".  Zero means that there is no limit.
+	maxRequestSizes map[string]int64
+
+	// The amount of a multipart form, in bytes, kept in memory while parsing.
+	// The remainder (e.g. of file uploads) is stored in temporary files.
+	maxMultipartMemory int64 = 32 << 20 // 32 MB
+
+	errRequestTooLarge = errors.New("revel: request body too large")
+)
+
+func init() {
+	OnAppStart(func() {
+		maxMultipartMemory = int64(Config.IntDefault("http.maxmultipartmemory", int(maxMultipartMemory)))
+
+		// Read the global limit, and those for particular controllers and actions,
+		// e.g. http.maxrequestsize.Hotels.Upload = 104857600
+		maxRequestSizes = make(map[string]int64)
+		for name, value := range Config.ActionOptions("http.maxrequestsize") {
+			maxRequestSizes[name], _ = strconv.ParseInt(value, 10, 64)
+		}
+	})
+}
+
+// maxRequestSize returns the limit on the size of the request body for the
+// given action (e.g. "Hotels.Upload"), or zero if it is unlimited.
+func maxRequestSize(action string) int64 {
+	for _, key := range ActionConfigKeys(action) {
+		if size, ok := maxRequestSizes[key]; ok {
+			return size
+		}
+	}
+	return 0
+}
+
+// limitedBody wraps a request body, failing reads beyond the limit.
+type limitedBody struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+	exceeded  bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, errRequestTooLarge
+	}
+	// Read one byte more than remains, to find out whether the limit is exceeded.
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n, b.remaining, b.exceeded = int(b.remaining), 0, true
+		return n, errRequestTooLarge
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
+
 func ParseParams(params *Params, req *Request) {
 	params.Query = req.URL.Query()
 
 	// Parse the body depending on the content type.
 	switch req.ContentType {
 	case "application/x-www-form-urlencoded":
-		// Typical form.
-		if err := req.ParseForm(); err != nil {
+		// Typical form.  The net/http package only reads the body of POST, PUT
+		// and PATCH requests, but a form may have any method (e.g. DELETE, using
+		// the MethodOverrideFilter).
+		method := req.Method
+		if method != "POST" && method != "PUT" && method != "PATCH" {
+			req.Method = "POST"
+		}
+		err := req.ParseForm()
+		req.Method = method
+		if err != nil {
 			WARN.Println("Error parsing request body:", err)
 		} else {
 			params.Form = req.Form
@@ -44,8 +120,7 @@ func ParseParams(params *Params, req *Request) {
 
 	case "multipart/form-data":
 		// Multipart form.
-		// TODO: Extract the multipart form param so app can set it.
-		if err := req.ParseMultipartForm(32 << 20 /* 32 MB */); err != nil {
+		if err := req.ParseMultipartForm(maxMultipartMemory); err != nil {
 			WARN.Println("Error parsing request body:", err)
 		} else {
 			params.Form = req.MultipartForm.Value
@@ -110,6 +185,18 @@ func (p *Params) calcValues() url.Values {
 }
 
 func ParamsFilter(c *Controller, fc []Filter) {
+	// Limit the size of the request body before it is parsed.  Requests that
+	// declare a larger body are rejected without reading it.
+	var body *limitedBody
+	if limit := maxRequestSize(c.Action); limit > 0 && c.Request.Body != nil {
+		if c.Request.ContentLength > limit {
+			c.Result = requestTooLarge(c, limit)
+			return
+		}
+		body = &limitedBody{ReadCloser: c.Request.Body, limit: limit, remaining: limit}
+		c.Request.Body = body
+	}
+
 	ParseParams(c.Params, c.Request)
 
 	// Clean up from the request.
@@ -130,5 +217,25 @@ func ParamsFilter(c *Controller, fc []Filter) {
 		}
 	}()
 
+	if body != nil && body.exceeded {
+		c.Result = requestTooLarge(c, body.limit)
+		return
+	}
+
 	fc[0](c, fc[1:])
+
+	// The action may have read the body itself (e.g. JSON), and failed on
+	// reaching the limit.
+	if body != nil && body.exceeded {
+		c.Result = requestTooLarge(c, body.limit)
+	}
+}
+
+// requestTooLarge returns the 413 error for a request body over the limit.
+func requestTooLarge(c *Controller, limit int64) Result {
+	c.Response.Status = http.StatusRequestEntityTooLarge
+	return c.RenderError(&Error{
+		Title:       "Request Entity Too Large",
+		Description: fmt.Sprintf("The request body exceeds the limit of %d bytes", limit),
+	})
 }
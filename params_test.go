@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"reflect"
 	"testing"
@@ -120,6 +121,75 @@ func TestMultipartForm(t *testing.T) {
 	}
 }
 
+func TestMaxRequestSize(t *testing.T) {
+	defer func() { maxRequestSizes = nil }()
+	maxRequestSizes = map[string]int64{
+		"":              10,
+		"hotels":        20,
+		"hotels.upload": int64(len(MULTIPART_FORM_DATA)),
+	}
+	eq(t, "Global limit", maxRequestSize("Application.Index"), int64(10))
+	eq(t, "Controller limit", maxRequestSize("Hotels.Show"), int64(20))
+	eq(t, "Action limit", maxRequestSize("Hotels.Upload"), int64(len(MULTIPART_FORM_DATA)))
+
+	newController := func(action string, contentLength int64) *Controller {
+		req := getMultipartRequest()
+		req.ContentLength = contentLength
+		c := NewController(NewRequest(req), NewResponse(httptest.NewRecorder()))
+		c.Action = action
+		return c
+	}
+
+	// A declared length over the limit is rejected up front.
+	c := newController("Application.Index", int64(len(MULTIPART_FORM_DATA)))
+	ParamsFilter(c, NilChain)
+	eq(t, "Status", c.Response.Status, http.StatusRequestEntityTooLarge)
+	if _, ok := c.Result.(ErrorResult); !ok {
+		t.Errorf("Expected an ErrorResult, got %T", c.Result)
+	}
+
+	// A body of unknown length is rejected once it exceeds the limit.
+	c = newController("Application.Index", -1)
+	ParamsFilter(c, NilChain)
+	eq(t, "Status", c.Response.Status, http.StatusRequestEntityTooLarge)
+
+	// So is one that the action reads itself.
+	c = newController("Hotels.Show", -1)
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.ContentType = "application/json"
+	ParamsFilter(c, []Filter{func(c *Controller, _ []Filter) {
+		if _, err := ioutil.ReadAll(c.Request.Body); err != nil {
+			c.Response.Status = http.StatusInternalServerError
+			c.Result = c.RenderError(err)
+		}
+	}})
+	eq(t, "Status", c.Response.Status, http.StatusRequestEntityTooLarge)
+
+	// Bodies within the limit for the action are parsed as usual.
+	c = newController("Hotels.Upload", -1)
+	ParamsFilter(c, NilChain)
+	eq(t, "Status", c.Response.Status, 0)
+	if !reflect.DeepEqual(expectedValues, map[string][]string(c.Params.Values)) {
+		t.Errorf("Param values: (expected) %v != %v (actual)",
+			expectedValues, map[string][]string(c.Params.Values))
+	}
+}
+
+// Test that the limits for controllers and actions are read from app.conf.
+func TestMaxRequestSizeConfig(t *testing.T) {
+	startFakeBookingApp()
+	defer setConfig(map[string]string{
+		"http.maxrequestsize":               "10",
+		"http.maxrequestsize.Hotels":        "20",
+		"http.maxrequestsize.Hotels.Upload": "30",
+		"http.maxrequestsizes":              "40",
+	})()
+	eq(t, "Global limit", maxRequestSize("Application.Index"), int64(10))
+	eq(t, "Controller limit", maxRequestSize("Hotels.Show"), int64(20))
+	eq(t, "Action limit", maxRequestSize("hotels.upload"), int64(30))
+	eq(t, "Limits", len(maxRequestSizes), 3)
+}
+
 func TestBind(t *testing.T) {
 	params := Params{
 		Values: url.Values{
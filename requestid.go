@@ -0,0 +1,59 @@
+package revel
+
+import (
+	"context"
+	"github.com/streadway/simpleuuid"
+	"net/http"
+	"time"
+)
+
+// The header carrying the request ID, configured by http.requestid.header.
+// An ID given by the client (or a proxy) in this header is used for the
+// request, and the ID is echoed in the response.
+var RequestIdHeader = "X-Request-Id"
+
+// The longest request ID accepted from the client.
+const maxRequestIdLength = 200
+
+type requestIdKey struct{}
+
+func init() {
+	OnAppStart(func() {
+		RequestIdHeader = Config.StringDefault("http.requestid.header", RequestIdHeader)
+	})
+}
+
+// RequestIdFromContext returns the ID of the request that the context belongs
+// to, or "" if there is none.  This allows code that is passed the request's
+// context (e.g. c.Request.Context()) to tag its log lines with the ID.
+func RequestIdFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
+
+// requestId returns the ID given in the request header, if it is acceptable,
+// or else a new one.
+func requestId(r *http.Request) string {
+	if id := r.Header.Get(RequestIdHeader); id != "" && validRequestId(id) {
+		return id
+	}
+	uuid, err := simpleuuid.NewTime(time.Now())
+	if err != nil {
+		panic(err)
+	}
+	return uuid.String()
+}
+
+// validRequestId returns true if the ID is short and made of printable ASCII,
+// so that it is safe to echo in the response and to write to the logs.
+func validRequestId(id string) bool {
+	if len(id) > maxRequestIdLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package revel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestId(t *testing.T) {
+	defer func() { mountedHandlers = nil }()
+	var ctx context.Context
+	Handle("/request-id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	}))
+
+	serve := func(incomingId string) (string, context.Context) {
+		resp := httptest.NewRecorder()
+		handle(resp, testRequest("GET", "/request-id", RequestIdHeader, incomingId))
+		return resp.Header().Get(RequestIdHeader), ctx
+	}
+
+	// The incoming ID is used, echoed, and carried by the context.
+	id, ctx := serve("abc-123")
+	eq(t, "Echoed ID", id, "abc-123")
+	eq(t, "Context ID", RequestIdFromContext(ctx), "abc-123")
+	eq(t, "Context cancelled", ctx.Err(), context.Canceled)
+
+	// Otherwise, or if the incoming ID is unacceptable, an ID is generated.
+	for _, incomingId := range []string{"", "has spaces", strings.Repeat("x", maxRequestIdLength+1)} {
+		id, ctx := serve(incomingId)
+		if id == "" || id == incomingId {
+			t.Errorf("Expected a generated ID for %q, got %q", incomingId, id)
+		}
+		eq(t, "Context ID", RequestIdFromContext(ctx), id)
+	}
+
+	// IDs are unique.
+	first, _ := serve("")
+	second, _ := serve("")
+	if first == second {
+		t.Errorf("Expected unique request IDs, got %s twice", first)
+	}
+}
@@ -122,6 +122,11 @@ func (r *RenderTemplateResult) Apply(req *Request, resp *Response) {
 		}
 	}()
 
+	// The client's copy may be current, according to the action's validators.
+	if notModified(req, resp, nil) {
+		return
+	}
+
 	chunked := Config.BoolDefault("results.chunked", false)
 
 	// If it's a HEAD request, throw away the bytes.
@@ -145,6 +150,9 @@ func (r *RenderTemplateResult) Apply(req *Request, resp *Response) {
 	// would carry a 200 status code)
 	var b bytes.Buffer
 	r.render(req, resp, &b)
+	if notModified(req, resp, b.Bytes()) {
+		return
+	}
 	if !chunked {
 		resp.Out.Header().Set("Content-Length", strconv.Itoa(b.Len()))
 	}
@@ -195,6 +203,10 @@ type RenderJsonResult struct {
 }
 
 func (r RenderJsonResult) Apply(req *Request, resp *Response) {
+	if notModified(req, resp, nil) {
+		return
+	}
+
 	var b []byte
 	var err error
 	if Config.BoolDefault("results.pretty", false) {
@@ -208,16 +220,17 @@ func (r RenderJsonResult) Apply(req *Request, resp *Response) {
 		return
 	}
 
-	if r.callback == "" {
-		resp.WriteHeader(http.StatusOK, "application/json; charset=utf-8")
-		resp.Out.Write(b)
-		return
+	contentType := "application/json; charset=utf-8"
+	if r.callback != "" {
+		contentType = "application/javascript; charset=utf-8"
+		b = append(append([]byte(r.callback+"("), b...), ");"...)
 	}
 
-	resp.WriteHeader(http.StatusOK, "application/javascript; charset=utf-8")
-	resp.Out.Write([]byte(r.callback + "("))
+	if notModified(req, resp, b) {
+		return
+	}
+	resp.WriteHeader(http.StatusOK, contentType)
 	resp.Out.Write(b)
-	resp.Out.Write([]byte(");"))
 }
 
 type RenderXmlResult struct {
@@ -225,6 +238,10 @@ type RenderXmlResult struct {
 }
 
 func (r RenderXmlResult) Apply(req *Request, resp *Response) {
+	if notModified(req, resp, nil) {
+		return
+	}
+
 	var b []byte
 	var err error
 	if Config.BoolDefault("results.pretty", false) {
@@ -238,6 +255,9 @@ func (r RenderXmlResult) Apply(req *Request, resp *Response) {
 		return
 	}
 
+	if notModified(req, resp, b) {
+		return
+	}
 	resp.WriteHeader(http.StatusOK, "application/xml; charset=utf-8")
 	resp.Out.Write(b)
 }
@@ -273,7 +293,8 @@ func (r *BinaryResult) Apply(req *Request, resp *Response) {
 	}
 	resp.Out.Header().Set("Content-Disposition", disposition)
 
-	// If we have a ReadSeeker, delegate to http.ServeContent
+	// If we have a ReadSeeker, delegate to http.ServeContent, which also
+	// answers conditional requests.
 	if rs, ok := r.Reader.(io.ReadSeeker); ok {
 		// http.ServeContent doesn't know about response.ContentType, so we set the respective header.
 		if resp.ContentType != "" {
@@ -281,12 +302,18 @@ func (r *BinaryResult) Apply(req *Request, resp *Response) {
 		}
 		http.ServeContent(resp.Out, req.Request, r.Name, r.ModTime, rs)
 	} else {
-		// Else, do a simple io.Copy.
-		if r.Length != -1 {
-			resp.Out.Header().Set("Content-Length", strconv.FormatInt(r.Length, 10))
+		// Else, answer conditional requests using the modification time (and any
+		// ETag set by the action), or do a simple io.Copy.
+		if !r.ModTime.IsZero() {
+			resp.SetLastModified(r.ModTime)
+		}
+		if !notModified(req, resp, nil) {
+			if r.Length != -1 {
+				resp.Out.Header().Set("Content-Length", strconv.FormatInt(r.Length, 10))
+			}
+			resp.WriteHeader(http.StatusOK, ContentTypeByFilename(r.Name))
+			io.Copy(resp.Out, r.Reader)
 		}
-		resp.WriteHeader(http.StatusOK, ContentTypeByFilename(r.Name))
-		io.Copy(resp.Out, r.Reader)
 	}
 
 	// Close the Reader if we can
@@ -341,9 +368,9 @@ func getRedirectUrl(item interface{}) (string, error) {
 			recvType = recvType.Elem()
 		}
 		action := recvType.Name() + "." + method.Name
-		actionDef := MainRouter.Reverse(action, make(map[string]string))
-		if actionDef == nil {
-			return "", errors.New("no route for action " + action)
+		actionDef, err := MainRouter.ReverseValues(action, nil)
+		if err != nil {
+			return "", err
 		}
 
 		return actionDef.String(), nil
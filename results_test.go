@@ -1,9 +1,11 @@
 package revel
 
 import (
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 // Test that the render response is as expected.
@@ -19,6 +21,61 @@ func TestBenchmarkRender(t *testing.T) {
 	}
 }
 
+// Test that conditional requests are answered with 304 Not Modified.
+func TestConditionalGet(t *testing.T) {
+	startFakeBookingApp()
+	apply := func(method string, header http.Header, setup func(*Response)) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/hotels/3.json", nil)
+		req.Header = header
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		if setup != nil {
+			setup(c.Response)
+		}
+		c.RenderJson(map[string]int{"id": 3}).Apply(c.Request, c.Response)
+		return resp
+	}
+
+	// The ETag is computed from the body, and the client's copy is current if
+	// it matches.
+	resp := apply("GET", http.Header{}, nil)
+	etag := resp.Header().Get("ETag")
+	if resp.Code != http.StatusOK || !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("Expected a strong ETag, got %d %q", resp.Code, etag)
+	}
+	for _, match := range []string{etag, `"other", W/` + etag, "*"} {
+		resp = apply("GET", http.Header{"If-None-Match": {match}}, nil)
+		eq(t, "Status for "+match, resp.Code, http.StatusNotModified)
+		eq(t, "Body for "+match, resp.Body.Len(), 0)
+	}
+	eq(t, "Status for another ETag", apply("GET", http.Header{"If-None-Match": {`"other"`}}, nil).Code, http.StatusOK)
+	eq(t, "Status for POST", apply("POST", http.Header{"If-None-Match": {etag}}, nil).Code, http.StatusOK)
+
+	// The action may supply its own validators.
+	modTime := time.Date(2014, 1, 2, 3, 4, 5, 0, time.UTC)
+	withValidators := func(resp *Response) {
+		resp.SetETag("v1", true)
+		resp.SetLastModified(modTime)
+	}
+	resp = apply("GET", http.Header{"If-None-Match": {`W/"v1"`}}, withValidators)
+	eq(t, "Status for the action's ETag", resp.Code, http.StatusNotModified)
+	eq(t, "ETag", resp.Header().Get("ETag"), `W/"v1"`)
+
+	since := func(t time.Time) http.Header {
+		return http.Header{"If-Modified-Since": {t.Format(http.TimeFormat)}}
+	}
+	eq(t, "Status if unmodified since", apply("GET", since(modTime), withValidators).Code, http.StatusNotModified)
+	eq(t, "Status if modified since", apply("GET", since(modTime.Add(-time.Hour)), withValidators).Code, http.StatusOK)
+
+	// Responses that may be compressed have weak ETags.
+	defer setConfig(map[string]string{"results.compressed": "true"})()
+	c, resp := testController(testRequest("GET", "/hotels/3.json", "Accept-Encoding", "gzip"))
+	CompressFilter(c, []Filter{func(c *Controller, _ []Filter) {
+		c.RenderJson(map[string]int{"id": 3}).Apply(c.Request, c.Response)
+	}})
+	eq(t, "Compressed ETag", resp.Header().Get("ETag"), "W/"+etag)
+}
+
 func BenchmarkRenderChunked(b *testing.B) {
 	startFakeBookingApp()
 	resp := httptest.NewRecorder()
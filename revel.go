@@ -1,6 +1,7 @@
 package revel
 
 import (
+	"fmt"
 	"github.com/robfig/config"
 	"go/build"
 	"io"
@@ -52,6 +53,10 @@ var (
 	HttpSslCert string // e.g. "/path/to/cert.pem"
 	HttpSslKey  string // e.g. "/path/to/key.pem"
 
+	// The scheme and host used to build absolute URLs to the app.
+	HttpScheme string // e.g. "https"
+	HttpHost   string // e.g. "www.example.com", "localhost:9000"
+
 	// All cookies dropped by the framework begin with this prefix.
 	CookiePrefix string
 
@@ -152,6 +157,12 @@ func Init(mode, importPath, srcPath string) {
 		}
 	}
 
+	HttpScheme = Config.StringDefault("http.scheme", "http")
+	if HttpSsl {
+		HttpScheme = Config.StringDefault("http.scheme", "https")
+	}
+	HttpHost = Config.StringDefault("http.host", defaultHttpHost())
+
 	AppName = Config.StringDefault("app.name", "(not set)")
 	CookiePrefix = Config.StringDefault("cookie.prefix", "REVEL")
 	CookieHttpOnly = Config.BoolDefault("cookie.httponly", false)
@@ -172,6 +183,22 @@ func Init(mode, importPath, srcPath string) {
 	Initialized = true
 }
 
+// defaultHttpHost returns the host (and port) on which the app is configured
+// to listen, for use when http.host is not set.
+func defaultHttpHost() string {
+	host := HttpAddr
+	if strings.Contains(host, "/") {
+		return "localhost" // Listening on a unix socket, which has no port.
+	}
+	if host == "" {
+		host = "localhost" // Listening on all interfaces.
+	}
+	if HttpPort == 0 || (HttpSsl && HttpPort == 443) || (!HttpSsl && HttpPort == 80) {
+		return host
+	}
+	return fmt.Sprintf("%s:%d", host, HttpPort)
+}
+
 // Create a logger using log.* directives in app.conf plus the current settings
 // on the default logger.
 func getLogger(name string) *log.Logger {
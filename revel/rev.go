@@ -34,6 +34,7 @@ var commands = []*Command{
 	cmdPackage,
 	cmdClean,
 	cmdTest,
+	cmdRoutes,
 }
 
 func main() {
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"github.com/robfig/revel"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+var cmdRoutes = &Command{
+	UsageLine: "routes [import path] [run mode] [--match METHOD PATH]",
+	Short:     "print the routing table of a Revel application",
+	Long: `
+Print the routing table of the Revel app named by the given import path,
+including the routes imported from modules.
+
+For example, to print the booking sample application's routes:
+
+    revel routes github.com/robfig/revel/samples/booking
+
+The run mode determines which modules are loaded, and defaults to "dev".
+
+To see which route a request would be routed to, pass --match with the
+request method and path.  The path may be a full URL to match on the host.
+For example:
+
+    revel routes github.com/robfig/revel/samples/booking dev --match GET /hotels/3
+`,
+}
+
+func init() {
+	cmdRoutes.Run = printRoutes
+}
+
+func printRoutes(args []string) {
+	// Separate the --match option from the positional arguments.
+	var matchArgs, posArgs []string
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--match" || args[i] == "-match" {
+			if i+2 >= len(args) {
+				errorf("--match requires a METHOD and PATH.\nRun 'revel help routes' for usage.\n")
+			}
+			matchArgs = args[i+1 : i+3]
+			i += 2
+			continue
+		}
+		posArgs = append(posArgs, args[i])
+	}
+
+	if len(posArgs) == 0 {
+		errorf("No import path given.\nRun 'revel help routes' for usage.\n")
+	}
+
+	mode := "dev"
+	if len(posArgs) >= 2 {
+		mode = posArgs[1]
+	}
+
+	// Find and parse app.conf, and load the modules.
+	revel.Init(mode, posArgs[0], "")
+
+	router := revel.NewRouter(path.Join(revel.BasePath, "conf", "routes"))
+	router.Extensions = revel.Config.BoolDefault("routes.extensions", false)
+	if err := router.Load(); err != nil {
+		errorf("Failed to load routes: %s", err)
+	}
+
+	if matchArgs != nil {
+		matchRoute(router, matchArgs[0], matchArgs[1])
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH\tACTION\tFIXED ARGS\tSOURCE")
+	for _, route := range router.Routes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", route.Method, route.Host+route.Path,
+			route.Action, strings.Join(route.FixedParams, ", "), routeSource(route))
+	}
+	w.Flush()
+}
+
+// matchRoute prints the route that the given request would be routed to.
+func matchRoute(router *revel.Router, method, url string) {
+	req, err := http.NewRequest(strings.ToUpper(method), url, nil)
+	if err != nil {
+		errorf("Invalid request %s %s: %s", method, url, err)
+	}
+
+	match := router.Route(req)
+	if match == nil {
+		fmt.Printf("No route matches %s %s\n", req.Method, url)
+		if allowed := router.AllowedMethods(req); allowed != nil {
+			fmt.Println("Allowed methods:", strings.Join(allowed, ", "))
+		}
+		return
+	}
+
+	route := match.Route
+	fmt.Printf("%s %s matches:\n\n", req.Method, url)
+	fmt.Printf("    %s %s %s\n", route.Method, route.Host+route.Path, route.Action)
+	fmt.Printf("    (%s)\n\n", routeSource(route))
+	// Status responses and redirects (e.g. 404, 301:/new/:id) name no controller.
+	if match.ControllerName == "" {
+		fmt.Println("Action: ", match.Action)
+		return
+	}
+	fmt.Printf("Action:  %s.%s\n", match.ControllerName, match.MethodName)
+	if match.Format != "" {
+		fmt.Println("Format: ", match.Format)
+	}
+
+	var params []string
+	for key, values := range match.Params {
+		for _, value := range values {
+			params = append(params, key+"="+value)
+		}
+	}
+	sort.Strings(params)
+	if len(params) > 0 {
+		fmt.Println("Params: ", strings.Join(params, ", "))
+	}
+	if len(match.FixedParams) > 0 {
+		fmt.Println("Fixed:  ", strings.Join(match.FixedParams, ", "))
+	}
+}
+
+// routeSource returns the file and line declaring the route, relative to the
+// app (or for modules, the source path) if possible.
+func routeSource(route *revel.Route) string {
+	routesPath, line := route.Source()
+	for _, basePath := range []string{revel.BasePath, revel.SourcePath} {
+		if rel, err := filepath.Rel(basePath, routesPath); err == nil && !strings.HasPrefix(rel, "..") {
+			routesPath = rel
+			break
+		}
+	}
+	return fmt.Sprintf("%s:%d", routesPath, line)
+}
@@ -2,10 +2,12 @@ package revel
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"github.com/robfig/pathtree"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"path"
@@ -15,23 +17,28 @@ import (
 
 type Route struct {
 	Method         string   // e.g. GET
+	Host           string   // e.g. ":tenant.example.com", "" (any host)
 	Path           string   // e.g. /app/:id
-	Action         string   // e.g. "Application.ShowApp", "404"
+	Action         string   // e.g. "Application.ShowApp", "404", "301:/new/:id"
 	ControllerName string   // e.g. "Application", ""
 	MethodName     string   // e.g. "ShowApp", ""
 	FixedParams    []string // e.g. "arg1","arg2","arg3" (CSV formatting)
 	TreePath       string   // e.g. "/GET/app/:id"
 
+	args       []*arg // Route arguments that carry a constraint.
+	err        error  // Set if the route could not be parsed.
 	routesPath string // e.g. /Users/robfig/gocode/src/myapp/conf/routes
 	line       int    // e.g. 3
 }
 
 type RouteMatch struct {
-	Action         string // e.g. 404
+	Action         string // e.g. 404, 301:/new/:id
 	ControllerName string // e.g. Application
 	MethodName     string // e.g. ShowApp
 	FixedParams    []string
 	Params         map[string][]string // e.g. {id: 123}
+	Format         string              // e.g. "json", if given by the URL extension.
+	Route          *Route              // The route that was matched.
 }
 
 type arg struct {
@@ -40,7 +47,68 @@ type arg struct {
 	constraint *regexp.Regexp
 }
 
+// RouteConstraints maps the names of the types that route arguments may be
+// declared with (e.g. "/users/:id<int>") to the pattern they must match.
+// Any other constraint is interpreted as a regular expression.
+var RouteConstraints = map[string]string{
+	"int":   `-?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"float": `-?[0-9]*\.?[0-9]+`,
+	"alpha": `[a-zA-Z]+`,
+	"alnum": `[a-zA-Z0-9]+`,
+	"hex":   `[0-9a-fA-F]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// parseArg parses a route path element declaring an argument, returning the
+// element in its plain form (e.g. ":id") and the constraint, if any.
+// The accepted forms are :id, :id<int>, :id<[0-9]+>, {id} and {<[0-9]+>id}.
+func parseArg(el string) (plain, constraint string) {
+	switch {
+	case strings.HasPrefix(el, "{") && strings.HasSuffix(el, "}"):
+		el = el[1 : len(el)-1]
+		if strings.HasPrefix(el, "<") {
+			if end := strings.LastIndex(el, ">"); end != -1 {
+				constraint, el = el[1:end], el[end+1:]
+			}
+		}
+		return ":" + el, constraint
+	case len(el) > 0 && (el[0] == ':' || el[0] == '*'):
+		if start := strings.Index(el, "<"); start != -1 && strings.HasSuffix(el, ">") {
+			el, constraint = el[:start], el[start+1:len(el)-1]
+		}
+	}
+	return el, constraint
+}
+
+// parsePath strips the constraints from the given route path, returning the
+// plain path and the constrained arguments.
+func parsePath(path string) (string, []*arg, error) {
+	var (
+		args     []*arg
+		elements = strings.Split(path, "/")
+	)
+	for i, el := range elements {
+		plain, constraint := parseArg(el)
+		elements[i] = plain
+		if constraint == "" {
+			continue
+		}
+		if pattern, ok := RouteConstraints[constraint]; ok {
+			constraint = pattern
+		}
+		regex, err := regexp.Compile("^(?:" + constraint + ")$")
+		if err != nil {
+			return path, nil, fmt.Errorf("Invalid constraint for route arg %s: %s", plain[1:], err)
+		}
+		args = append(args, &arg{name: plain[1:], index: i, constraint: regex})
+	}
+	return strings.Join(elements, "/"), args, nil
+}
+
 // Prepares the route to be used in matching.
+// The path may be prefixed by a host pattern, e.g. "api.example.com/users" or
+// ":tenant.example.com/dashboard", to restrict the route to matching hosts.
 func NewRoute(method, path, action, fixedArgs, routesPath string, line int) (r *Route) {
 	// Handle fixed arguments
 	argsReader := strings.NewReader(fixedArgs)
@@ -50,12 +118,30 @@ func NewRoute(method, path, action, fixedArgs, routesPath string, line int) (r *
 		ERROR.Printf("Invalid fixed parameters (%v): for string '%v'", err.Error(), fixedArgs)
 	}
 
+	// Split off the host pattern, if any.
+	var host string
+	if slash := strings.Index(path, "/"); slash > 0 {
+		host, path = strings.ToLower(path[:slash]), path[slash:]
+	}
+
+	// Strip the argument constraints.
+	path, args, err := parsePath(path)
+	if err == nil {
+		err = checkHostPattern(host)
+	}
+	if err != nil {
+		ERROR.Print(err)
+	}
+
 	r = &Route{
 		Method:      strings.ToUpper(method),
+		Host:        host,
 		Path:        path,
 		Action:      action,
 		FixedParams: fargs,
 		TreePath:    treePath(strings.ToUpper(method), path),
+		args:        args,
+		err:         err,
 		routesPath:  routesPath,
 		line:        line,
 	}
@@ -66,6 +152,11 @@ func NewRoute(method, path, action, fixedArgs, routesPath string, line int) (r *
 		return
 	}
 
+	// Status responses and redirects do not name a controller.
+	if _, _, ok := parseStatusAction(action); ok {
+		return
+	}
+
 	actionSplit := strings.Split(action, ".")
 	if len(actionSplit) == 2 {
 		r.ControllerName = actionSplit[0]
@@ -75,6 +166,71 @@ func NewRoute(method, path, action, fixedArgs, routesPath string, line int) (r *
 	return
 }
 
+// parseStatusAction parses an action that responds with a fixed status instead
+// of invoking a controller, e.g. "404", "410", or a redirect such as
+// "301:/new/path/:id".  ok is false if the action is not of this form.
+func parseStatusAction(action string) (status int, target string, ok bool) {
+	code := action
+	if colon := strings.Index(action, ":"); colon != -1 {
+		code, target = action[:colon], action[colon+1:]
+	}
+	if len(code) != 3 || code[0] < '3' || code[0] > '5' {
+		return 0, "", false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return 0, "", false
+		}
+	}
+	status = int(code[0]-'0')*100 + int(code[1]-'0')*10 + int(code[2]-'0')
+	return status, target, true
+}
+
+// redirectUrl fills in the redirect target with the route params, e.g.
+// "/new/path/:id" => "/new/path/123".  The query string of the request is
+// carried over, unless the target has its own.
+func redirectUrl(target string, params url.Values, rawQuery string) string {
+	elements := strings.Split(target, "/")
+	for i, el := range elements {
+		if len(el) < 2 || (el[0] != ':' && el[0] != '*') {
+			continue
+		}
+		if vals, ok := params[el[1:]]; ok && len(vals) > 0 {
+			elements[i] = escapeArg(el, vals[0])
+		}
+	}
+	redirect := strings.Join(elements, "/")
+	if rawQuery != "" && !strings.Contains(redirect, "?") {
+		redirect += "?" + rawQuery
+	}
+	return redirect
+}
+
+// escapeArg escapes the value of a route arg for insertion into a path.  A star
+// arg may span several path segments, so its slashes are kept.
+func escapeArg(el, val string) string {
+	if el[0] != '*' {
+		return url.PathEscape(val)
+	}
+	segments := strings.Split(val, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// endsInStar returns true if the route's path ends in a star arg.
+func (r *Route) endsInStar() bool {
+	elements := splitPath(r.Path)
+	return strings.HasPrefix(elements[len(elements)-1], "*")
+}
+
+// Source returns the routes file and line number on which the route was
+// declared.
+func (r *Route) Source() (routesPath string, line int) {
+	return r.routesPath, r.line + 1
+}
+
 func treePath(method, path string) string {
 	if method == "*" {
 		method = ":METHOD"
@@ -82,33 +238,277 @@ func treePath(method, path string) string {
 	return "/" + method + path
 }
 
+// treeShape returns the tree path with the wildcard names removed.  Routes with
+// the same shape occupy the same place in the tree.
+func treeShape(treePath string) string {
+	elements := strings.Split(treePath, "/")
+	for i, el := range elements {
+		if el != "" && (el[0] == ':' || el[0] == '*') {
+			elements[i] = el[:1]
+		}
+	}
+	return strings.Join(elements, "/")
+}
+
+// splitPath splits a path into its elements, ignoring leading and trailing
+// slashes (in the same way as the tree).
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// satisfies returns true if the given params satisfy the route's constraints.
+func (r *Route) satisfies(params url.Values) bool {
+	for _, arg := range r.args {
+		if !arg.constraint.MatchString(params.Get(arg.name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// match checks the given method and path against the route, returning the
+// route params if it matches.  This is the slow equivalent of a tree lookup,
+// used when the route found in the tree does not satisfy its constraints.
+func (r *Route) match(method, path string, hostParams url.Values) (url.Values, bool) {
+	if r.Method != method && r.Method != "*" && !(r.Method == "GET" && method == "HEAD") {
+		return nil, false
+	}
+
+	params := make(url.Values)
+	for k, v := range hostParams {
+		params[k] = v
+	}
+	if r.Method == "*" {
+		params.Set("METHOD", method)
+	}
+
+	var (
+		routeElements = splitPath(r.Path)
+		elements      = splitPath(path)
+	)
+	for i, el := range routeElements {
+		switch {
+		case strings.HasPrefix(el, "*"):
+			if i >= len(elements) || elements[i] == "" {
+				return nil, false
+			}
+			params.Set(el[1:], strings.Join(elements[i:], "/"))
+			return params, r.satisfies(params)
+		case i >= len(elements):
+			return nil, false
+		case strings.HasPrefix(el, ":"):
+			params.Set(el[1:], elements[i])
+		case el != elements[i]:
+			return nil, false
+		}
+	}
+	if len(elements) != len(routeElements) {
+		return nil, false
+	}
+	return params, r.satisfies(params)
+}
+
+// checkHostPattern returns an error if a label of the host pattern is empty,
+// e.g. in ".example.com" or "a..example.com".
+func checkHostPattern(host string) error {
+	if host == "" {
+		return nil
+	}
+	for _, label := range hostLabels(host) {
+		if label == "" || label == ":" {
+			return fmt.Errorf("Invalid host pattern %s: empty label", host)
+		}
+	}
+	return nil
+}
+
+// hostLabels splits a host (or host pattern) into its dot-separated labels.
+func hostLabels(host string) []string {
+	return strings.Split(strings.ToLower(host), ".")
+}
+
+// requestHost returns the host the request was addressed to, without the port.
+func requestHost(req *http.Request) string {
+	host := req.Host
+	if host == "" && req.URL != nil {
+		host = req.URL.Host
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return host
+}
+
+// hostTree holds the routes that are restricted to a host pattern.
+type hostTree struct {
+	host   string   // e.g. ":tenant.example.com"
+	labels []string // e.g. ":tenant", "example", "com"
+	tree   *pathtree.Node
+}
+
+// match checks the given request host against the pattern, returning the
+// values of any captured labels.
+func (h *hostTree) match(host string) (params url.Values, ok bool) {
+	labels := hostLabels(host)
+	if len(labels) != len(h.labels) {
+		return nil, false
+	}
+	for i, label := range h.labels {
+		if strings.HasPrefix(label, ":") {
+			if params == nil {
+				params = make(url.Values)
+			}
+			params.Set(label[1:], labels[i])
+			continue
+		}
+		if label != labels[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
 type Router struct {
-	Routes []*Route
-	Tree   *pathtree.Node
-	path   string // path to the routes file
+	Routes     []*Route
+	Tree       *pathtree.Node // Routes that apply to any host.
+	Extensions bool           // Select the format from the URL extension, e.g. /hotels/1.json
+	hostTrees  []*hostTree    // Routes restricted to a host, in declaration order.
+	path       string         // path to the routes file
 }
 
-var notFound = &RouteMatch{Action: "404"}
+// formatExtensions maps the URL extensions recognized by the router to the
+// request format they select.
+var formatExtensions = map[string]string{
+	".html": "html",
+	".json": "json",
+	".xml":  "xml",
+	".txt":  "txt",
+}
+
+// splitExtension splits a recognized format extension off the path.  The
+// format is empty if the path has no such extension.
+func splitExtension(urlPath string) (string, string) {
+	ext := path.Ext(urlPath)
+	if format, ok := formatExtensions[ext]; ok {
+		return urlPath[:len(urlPath)-len(ext)], format
+	}
+	return urlPath, ""
+}
 
+// Route returns the route matching the given request, or nil if there is none.
+// Routes restricted to a host take precedence over those that apply to any host.
+//
+// If Extensions is set, a recognized extension (e.g. ".json") is stripped from
+// the path before matching, and returned as the match's Format.  Routes that
+// end in a star arg (e.g. /public/*filepath) keep the extension in the arg.
 func (router *Router) Route(req *http.Request) *RouteMatch {
-	leaf, expansions := router.Tree.Find(treePath(req.Method, req.URL.Path))
+	if !router.Extensions {
+		return router.route(req)
+	}
+	strippedPath, format := splitExtension(req.URL.Path)
+	if format == "" {
+		return router.route(req)
+	}
+	strippedReq, strippedUrl := *req, *req.URL
+	strippedUrl.Path = strippedPath
+	strippedReq.URL = &strippedUrl
+	if match := router.route(&strippedReq); match != nil && !match.Route.endsInStar() {
+		match.Format = format
+		return match
+	}
+	return router.route(req)
+}
+
+// route returns the route matching the given request, as is.
+func (router *Router) route(req *http.Request) *RouteMatch {
+	host := requestHost(req)
+	for _, ht := range router.hostTrees {
+		hostParams, ok := ht.match(host)
+		if !ok {
+			continue
+		}
+		if route, params := router.find(ht.tree, ht.host, hostParams, req); route != nil {
+			return newRouteMatch(route, params)
+		}
+	}
+	if route, params := router.find(router.Tree, "", nil, req); route != nil {
+		return newRouteMatch(route, params)
+	}
+	return nil
+}
+
+// routeMethods are the methods considered when determining the methods allowed
+// for a path.
+var routeMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"}
+
+// AllowedMethods returns the methods for which the request's path is routed,
+// or nil if the path is not routed for any method.  Since Revel answers OPTIONS
+// requests for routed paths, OPTIONS is always included in a non-nil result.
+func (router *Router) AllowedMethods(req *http.Request) []string {
+	var allowed []string
+	for _, method := range routeMethods {
+		methodReq := *req
+		methodReq.Method = method
+		match := router.Route(&methodReq)
+		if match == nil {
+			continue
+		}
+		// Routes that explicitly respond with an error do not count.
+		if status, _, ok := parseStatusAction(match.Action); ok && status >= 400 {
+			continue
+		}
+		allowed = append(allowed, method)
+	}
+	if allowed != nil {
+		allowed = append(allowed, "OPTIONS")
+	}
+	return allowed
+}
+
+// find looks up the request in the given tree.  Any params captured from the
+// host are added to the route params.
+//
+// If the route found does not satisfy its constraints, the routes for the host
+// are checked in order, so that the request falls through to the next route.
+func (router *Router) find(tree *pathtree.Node, host string, hostParams url.Values, req *http.Request) (*Route, url.Values) {
+	leaf, expansions := tree.Find(treePath(req.Method, req.URL.Path))
 	if leaf == nil {
-		return nil
+		return nil, nil
 	}
 	route := leaf.Value.(*Route)
 
 	// Create a map of the route parameters.
 	var params url.Values
-	if len(expansions) > 0 {
+	if len(expansions) > 0 || len(hostParams) > 0 {
 		params = make(url.Values)
+		for k, v := range hostParams {
+			params[k] = v
+		}
 		for i, v := range expansions {
 			params[leaf.Wildcards[i]] = []string{v}
 		}
 	}
 
-	// Special handling for explicit 404's.
-	if route.Action == "404" {
-		return notFound
+	if route.satisfies(params) {
+		return route, params
+	}
+
+	for _, route := range router.Routes {
+		if route.Host != host {
+			continue
+		}
+		if params, ok := route.match(req.Method, req.URL.Path, hostParams); ok {
+			return route, params
+		}
+	}
+	return nil, nil
+}
+
+// newRouteMatch returns the match for the given route and params.
+func newRouteMatch(route *Route, params url.Values) *RouteMatch {
+	// Special handling for explicit status responses (e.g. 404's) and redirects.
+	if _, _, ok := parseStatusAction(route.Action); ok {
+		return &RouteMatch{Action: route.Action, Params: params, Route: route}
 	}
 
 	// If the action is variablized, replace into it with the captured args.
@@ -125,13 +525,26 @@ func (router *Router) Route(req *http.Request) *RouteMatch {
 		MethodName:     methodName,
 		Params:         params,
 		FixedParams:    route.FixedParams,
+		Route:          route,
 	}
 }
 
 // Refresh re-reads the routes file and re-calculates the routing table.
 // Returns an error if a specified action could not be found.
 func (router *Router) Refresh() (err *Error) {
-	router.Routes, err = parseRoutesFile(router.path, true)
+	router.Routes, err = parseRoutesFile(router.path, "", true)
+	if err != nil {
+		return
+	}
+	err = router.updateTree()
+	return
+}
+
+// Load reads the routes file (and the routes of any modules it imports) and
+// calculates the routing table, without checking that the routed actions exist.
+// This allows the routing table to be inspected outside of a running app.
+func (router *Router) Load() (err *Error) {
+	router.Routes, err = parseRoutesFile(router.path, "", false)
 	if err != nil {
 		return
 	}
@@ -141,12 +554,26 @@ func (router *Router) Refresh() (err *Error) {
 
 func (router *Router) updateTree() *Error {
 	router.Tree = pathtree.New()
+	router.hostTrees = nil
+
+	// Routes that share their place in the tree with an earlier, constrained
+	// route are left out of the tree.  They are found by Route when the
+	// earlier route's constraints are not satisfied.
+	constrained := make(map[string]bool)
 	for _, route := range router.Routes {
-		err := router.Tree.Add(route.TreePath, route)
+		tree := router.treeForHost(route.Host)
+		shape := route.Host + treeShape(route.TreePath)
+		if constrained[shape] {
+			continue
+		}
+		if len(route.args) > 0 {
+			constrained[shape] = true
+		}
+		err := tree.Add(route.TreePath, route)
 
 		// Allow GETs to respond to HEAD requests.
 		if err == nil && route.Method == "GET" {
-			err = router.Tree.Add(treePath("HEAD", route.Path), route)
+			err = tree.Add(treePath("HEAD", route.Path), route)
 		}
 
 		// Error adding a route to the pathtree.
@@ -157,8 +584,25 @@ func (router *Router) updateTree() *Error {
 	return nil
 }
 
+// treeForHost returns the tree holding the routes for the given host pattern,
+// creating it if necessary.
+func (router *Router) treeForHost(host string) *pathtree.Node {
+	if host == "" {
+		return router.Tree
+	}
+	for _, ht := range router.hostTrees {
+		if ht.host == host {
+			return ht.tree
+		}
+	}
+	ht := &hostTree{host, hostLabels(host), pathtree.New()}
+	router.hostTrees = append(router.hostTrees, ht)
+	return ht.tree
+}
+
 // parseRoutesFile reads the given routes file and returns the contained routes.
-func parseRoutesFile(routesPath string, validate bool) ([]*Route, *Error) {
+// The prefix (e.g. "/api/v1") is prepended to every route path.
+func parseRoutesFile(routesPath, prefix string, validate bool) ([]*Route, *Error) {
 	contentBytes, err := ioutil.ReadFile(routesPath)
 	if err != nil {
 		return nil, &Error{
@@ -166,12 +610,17 @@ func parseRoutesFile(routesPath string, validate bool) ([]*Route, *Error) {
 			Description: err.Error(),
 		}
 	}
-	return parseRoutes(routesPath, string(contentBytes), validate)
+	return parseRoutes(routesPath, prefix, string(contentBytes), validate)
 }
 
 // parseRoutes reads the content of a routes file into the routing table.
-func parseRoutes(routesPath, content string, validate bool) ([]*Route, *Error) {
-	var routes []*Route
+// The prefix (e.g. "/api/v1") is prepended to every route path.
+func parseRoutes(routesPath, prefix, content string, validate bool) ([]*Route, *Error) {
+	var (
+		routes   []*Route
+		prefixes = []string{prefix} // The stack of enclosing PREFIX blocks.
+		lastOpen int                // The line of the last PREFIX block opened.
+	)
 
 	// For each line..
 	for n, line := range strings.Split(content, "\n") {
@@ -179,11 +628,41 @@ func parseRoutes(routesPath, content string, validate bool) ([]*Route, *Error) {
 		if len(line) == 0 || line[0] == '#' {
 			continue
 		}
+		prefix := prefixes[len(prefixes)-1]
+
+		// Handle blocks of routes sharing a path prefix.
+		// e.g. "PREFIX /api/v1 {" ... "}"
+		if isPrefixLine(line) {
+			blockPrefix, err := parsePrefixLine(line)
+			if err != nil {
+				return nil, routeError(err, routesPath, content, n)
+			}
+			prefixes = append(prefixes, prefixPath(prefix, blockPrefix))
+			lastOpen = n
+			continue
+		}
+		if line == "}" {
+			if len(prefixes) == 1 {
+				return nil, routeError(errors.New("Unexpected }: no PREFIX block is open"),
+					routesPath, content, n)
+			}
+			prefixes = prefixes[:len(prefixes)-1]
+			continue
+		}
 
 		// Handle included routes from modules.
 		// e.g. "module:testrunner" imports all routes from that module.
+		// e.g. "module:jobs /admin" imports them under the given prefix.
 		if strings.HasPrefix(line, "module:") {
-			moduleRoutes, err := getModuleRoutes(line[len("module:"):], validate)
+			fields := strings.Fields(line[len("module:"):])
+			moduleName, modulePrefix := "", prefix
+			if len(fields) > 0 {
+				moduleName = fields[0]
+			}
+			if len(fields) > 1 {
+				modulePrefix = prefixPath(prefix, fields[1])
+			}
+			moduleRoutes, err := getModuleRoutes(moduleName, modulePrefix, validate)
 			if err != nil {
 				return nil, routeError(err, routesPath, content, n)
 			}
@@ -191,13 +670,31 @@ func parseRoutes(routesPath, content string, validate bool) ([]*Route, *Error) {
 			continue
 		}
 
+		// Handle resource declarations, which expand to the standard actions.
+		// e.g. "RESOURCE /hotels Hotels"
+		if isResourceLine(line) {
+			resourceRoutes, err := parseResourceLine(line, prefix, routesPath, n)
+			if err != nil {
+				return nil, routeError(err, routesPath, content, n)
+			}
+			for _, route := range resourceRoutes {
+				if validate {
+					if err := validateRoute(route); err != nil {
+						return nil, routeError(err, routesPath, content, n)
+					}
+				}
+			}
+			routes = append(routes, resourceRoutes...)
+			continue
+		}
+
 		// A single route
 		method, path, action, fixedArgs, found := parseRouteLine(line)
 		if !found {
 			continue
 		}
 
-		route := NewRoute(method, path, action, fixedArgs, routesPath, n)
+		route := NewRoute(method, prefixPath(prefix, path), action, fixedArgs, routesPath, n)
 		routes = append(routes, route)
 
 		if validate {
@@ -207,13 +704,141 @@ func parseRoutes(routesPath, content string, validate bool) ([]*Route, *Error) {
 		}
 	}
 
+	if len(prefixes) > 1 {
+		return nil, routeError(errors.New("PREFIX block is not closed"), routesPath, content, lastOpen)
+	}
+
+	return routes, nil
+}
+
+func isPrefixLine(line string) bool {
+	fields := strings.Fields(line)
+	return len(fields) > 0 && strings.EqualFold(fields[0], "PREFIX")
+}
+
+// parsePrefixLine returns the path prefix declared by the opening line of a
+// PREFIX block, e.g. "PREFIX /api/v1 {".
+func parsePrefixLine(line string) (string, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 || fields[2] != "{" || !strings.HasPrefix(fields[1], "/") {
+		return "", fmt.Errorf("Expected PREFIX /path {, got: %s", line)
+	}
+	return fields[1], nil
+}
+
+// prefixPath inserts the prefix into the route path, after its host pattern
+// (if any).  e.g. "/api" and "api.example.com/users" => "api.example.com/api/users"
+func prefixPath(prefix, path string) string {
+	slash := strings.Index(path, "/")
+	if prefix == "" || slash == -1 {
+		return path
+	}
+	return path[:slash] + strings.TrimRight(prefix, "/") + path[slash:]
+}
+
+// resourceActions are the routes declared by a resource, in priority order.
+// Paths are relative to the resource path.
+var resourceActions = []struct{ method, path, action string }{
+	{"GET", "", "Index"},
+	{"GET", "/new", "New"},
+	{"POST", "", "Create"},
+	{"GET", "/:id", "Show"},
+	{"GET", "/:id/edit", "Edit"},
+	{"PUT", "/:id", "Update"},
+	{"PATCH", "/:id", "Update"},
+	{"DELETE", "/:id", "Destroy"},
+}
+
+func isResourceLine(line string) bool {
+	fields := strings.Fields(line)
+	return len(fields) > 0 && strings.EqualFold(fields[0], "RESOURCE")
+}
+
+// parseResourceLine expands a resource declaration into its routes.  The
+// actions may be restricted with an "only:" or "except:" list.  For example:
+//   RESOURCE /hotels Hotels
+//   RESOURCE /hotels Hotels only:Index,Show
+//   RESOURCE /hotels Hotels except:Edit,Destroy
+func parseResourceLine(line, prefix, routesPath string, n int) ([]*Route, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 && len(fields) != 4 {
+		return nil, fmt.Errorf("Expected RESOURCE path Controller [only:Actions|except:Actions], got: %s", line)
+	}
+	path, controllerName := strings.TrimRight(prefixPath(prefix, fields[1]), "/"), fields[2]
+
+	// Determine which actions to include.
+	include := func(action string) bool { return true }
+	if len(fields) == 4 {
+		option := strings.SplitN(fields[3], ":", 2)
+		if len(option) != 2 {
+			return nil, fmt.Errorf("Expected only:Actions or except:Actions, got: %s", fields[3])
+		}
+		actions := strings.Split(option[1], ",")
+		for _, action := range actions {
+			if !isResourceAction(action) {
+				return nil, fmt.Errorf("Unknown resource action: %s", action)
+			}
+		}
+		switch option[0] {
+		case "only":
+			include = func(action string) bool { return containsFold(actions, action) }
+		case "except":
+			include = func(action string) bool { return !containsFold(actions, action) }
+		default:
+			return nil, fmt.Errorf("Expected only:Actions or except:Actions, got: %s", fields[3])
+		}
+	}
+
+	var routes []*Route
+	for _, ra := range resourceActions {
+		if !include(ra.action) {
+			continue
+		}
+		routePath := path + ra.path
+		if !strings.Contains(routePath, "/") {
+			routePath += "/"
+		}
+		routes = append(routes, NewRoute(ra.method, routePath, controllerName+"."+ra.action, "", routesPath, n))
+	}
 	return routes, nil
 }
 
-// validateRoute checks that every specified action exists.
+func isResourceAction(action string) bool {
+	for _, ra := range resourceActions {
+		if strings.EqualFold(ra.action, action) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, el := range list {
+		if strings.EqualFold(el, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateRoute checks that the route was parsed and that every specified
+// action exists.
 func validateRoute(route *Route) error {
-	// Skip 404s
-	if route.Action == "404" {
+	if route.err != nil {
+		return route.err
+	}
+
+	// Check status responses and redirects, which have no action to load.
+	if status, target, ok := parseStatusAction(route.Action); ok {
+		isRedirect := status >= 300 && status < 400
+		switch {
+		case http.StatusText(status) == "":
+			return fmt.Errorf("Unknown status code: %d", status)
+		case isRedirect && target == "":
+			return fmt.Errorf("Redirect %d requires a target, e.g. %d:/new/path", status, status)
+		case !isRedirect && target != "":
+			return fmt.Errorf("Status %d does not take a target: %s", status, route.Action)
+		}
 		return nil
 	}
 
@@ -261,8 +886,8 @@ func routeError(err error, routesPath, content string, n int) *Error {
 }
 
 // getModuleRoutes loads the routes file for the given module and returns the
-// list of routes.
-func getModuleRoutes(moduleName string, validate bool) ([]*Route, *Error) {
+// list of routes, with the given prefix prepended to their paths.
+func getModuleRoutes(moduleName, prefix string, validate bool) ([]*Route, *Error) {
 	// Look up the module.  It may be not found due to the common case of e.g. the
 	// testrunner module being active only in dev mode.
 	module, found := ModuleByName(moduleName)
@@ -270,7 +895,7 @@ func getModuleRoutes(moduleName string, validate bool) ([]*Route, *Error) {
 		INFO.Println("Skipping routes for inactive module", moduleName)
 		return nil, nil
 	}
-	return parseRoutesFile(path.Join(module.Path, "conf", "routes"), validate)
+	return parseRoutesFile(path.Join(module.Path, "conf", "routes"), prefix, validate)
 }
 
 // Groups:
@@ -311,14 +936,66 @@ func (a *ActionDefinition) String() string {
 	return a.Url
 }
 
+// AbsoluteUrl returns the URL including the scheme and host, as configured by
+// http.scheme and http.host in app.conf.  This is useful for URLs used outside
+// of the app's pages, e.g. in emails.
+func (a *ActionDefinition) AbsoluteUrl() string {
+	if strings.HasPrefix(a.Url, "//") {
+		return HttpScheme + ":" + a.Url
+	}
+	return HttpScheme + "://" + HttpHost + a.Url
+}
+
+// WithFormat returns a copy of the action definition, with the extension for
+// the given format (e.g. "json") added to the URL path.  The router recognizes
+// the extension if its Extensions option is set.
+func (a *ActionDefinition) WithFormat(format string) *ActionDefinition {
+	actionDef := *a
+	host, urlPath, query := "", a.Url, ""
+	if i := strings.Index(urlPath, "?"); i != -1 {
+		urlPath, query = urlPath[:i], urlPath[i:]
+	}
+	if strings.HasPrefix(urlPath, "//") {
+		if i := strings.Index(urlPath[2:], "/"); i != -1 {
+			host, urlPath = urlPath[:i+2], urlPath[i+2:]
+		}
+	}
+	if len(urlPath) > 1 {
+		urlPath = strings.TrimSuffix(urlPath, "/")
+	}
+	actionDef.Url = host + urlPath + "." + format + query
+	return &actionDef
+}
+
+// Reverse returns the route for the given action, with the given args filled
+// in.  Errors are logged, and result in a nil return.  See ReverseValues.
 func (router *Router) Reverse(action string, argValues map[string]string) *ActionDefinition {
+	args := make(url.Values, len(argValues))
+	for k, v := range argValues {
+		args.Set(k, v)
+	}
+	actionDef, err := router.ReverseValues(action, args)
+	if err != nil {
+		ERROR.Println(err)
+		return nil
+	}
+	return actionDef
+}
+
+// ReverseValues returns the route for the given action.  The args are inserted
+// into the route's host and path, and any that remain are added to the query
+// string.  The first route for the action that can be filled in is used.
+//
+// An error is returned if the action has no route, or if its routes require
+// args that are missing or that do not satisfy their constraints.
+func (router *Router) ReverseValues(action string, args url.Values) (*ActionDefinition, error) {
 	actionSplit := strings.Split(action, ".")
 	if len(actionSplit) != 2 {
-		ERROR.Print("revel/router: reverse router got invalid action ", action)
-		return nil
+		return nil, fmt.Errorf("revel/router: reverse router got invalid action %s", action)
 	}
 	controllerName, methodName := actionSplit[0], actionSplit[1]
 
+	var routeErr error
 	for _, route := range router.Routes {
 		// Skip routes without either a ControllerName or MethodName
 		if route.ControllerName == "" || route.MethodName == "" {
@@ -332,67 +1009,122 @@ func (router *Router) Reverse(action string, argValues map[string]string) *Actio
 			(!methodWildcard && route.MethodName != methodName) {
 			continue
 		}
+
+		// Copy the args, so that the caller's are left alone.
+		argValues := make(url.Values, len(args))
+		for k, v := range args {
+			argValues[k] = v
+		}
 		if controllerWildcard {
-			argValues[route.ControllerName[1:]] = controllerName
+			argValues.Set(route.ControllerName[1:], controllerName)
 		}
 		if methodWildcard {
-			argValues[route.MethodName[1:]] = methodName
+			argValues.Set(route.MethodName[1:], methodName)
 		}
 
-		// Build up the URL.
-		var (
-			queryValues  = make(url.Values)
-			pathElements = strings.Split(route.Path, "/")
-		)
-		for i, el := range pathElements {
-			if el == "" || el[0] != ':' {
-				continue
-			}
-
-			val, ok := argValues[el[1:]]
-			if !ok {
-				val = "<nil>"
-				ERROR.Print("revel/router: reverse route missing route arg ", el[1:])
+		actionDef, err := route.reverse(argValues)
+		if err != nil {
+			// Remember the first error, in case no route works out.
+			if routeErr == nil {
+				routeErr = fmt.Errorf("revel/router: reversing %s: %s", action, err)
 			}
-			pathElements[i] = val
-			delete(argValues, el[1:])
 			continue
 		}
+		actionDef.Action = action
+		return actionDef, nil
+	}
 
-		// Add any args that were not inserted into the path into the query string.
-		for k, v := range argValues {
-			queryValues.Set(k, v)
-		}
+	if routeErr != nil {
+		return nil, routeErr
+	}
+	return nil, fmt.Errorf("revel/router: no route found for action %s", action)
+}
 
-		// Calculate the final URL and Method
-		url := strings.Join(pathElements, "/")
-		if len(queryValues) > 0 {
-			url += "?" + queryValues.Encode()
+// reverse fills in the route with the given args, which are consumed.
+func (r *Route) reverse(argValues url.Values) (*ActionDefinition, error) {
+	// takeArg removes and returns the arg for a host or path element.
+	takeArg := func(el string) (string, error) {
+		name := el[1:]
+		vals, ok := argValues[name]
+		if !ok || len(vals) == 0 {
+			return "", fmt.Errorf("missing route arg %s", name)
+		}
+		for _, arg := range r.args {
+			if arg.name == name && !arg.constraint.MatchString(vals[0]) {
+				return "", fmt.Errorf("route arg %s=%s does not match %s", name, vals[0], arg.constraint)
+			}
 		}
+		delete(argValues, name)
+		return vals[0], nil
+	}
 
-		method := route.Method
-		star := false
-		if route.Method == "*" {
-			method = "GET"
-			star = true
+	// Build up the host, if the route is restricted to one.
+	var host string
+	if r.Host != "" {
+		hostElements := hostLabels(r.Host)
+		for i, el := range hostElements {
+			if !strings.HasPrefix(el, ":") {
+				continue
+			}
+			val, err := takeArg(el)
+			if err != nil {
+				return nil, err
+			}
+			hostElements[i] = val
 		}
+		host = strings.Join(hostElements, ".")
+	}
 
-		return &ActionDefinition{
-			Url:    url,
-			Method: method,
-			Star:   star,
-			Action: action,
-			Args:   argValues,
-			Host:   "TODO",
+	// Build up the URL, escaping the args inserted into the path.
+	pathElements := strings.Split(r.Path, "/")
+	for i, el := range pathElements {
+		if el == "" || (el[0] != ':' && el[0] != '*') {
+			continue
+		}
+		val, err := takeArg(el)
+		if err != nil {
+			return nil, err
 		}
+		pathElements[i] = escapeArg(el, val)
 	}
-	ERROR.Println("Failed to find reverse route:", action, argValues)
-	return nil
+
+	// Add any args that were not inserted into the path into the query string.
+	url := strings.Join(pathElements, "/")
+	if len(argValues) > 0 {
+		url += "?" + argValues.Encode()
+	}
+
+	// Routes on another host require a (scheme-relative) absolute URL.
+	if host != "" {
+		url = "//" + host + url
+	}
+
+	// Calculate the Method
+	method := r.Method
+	star := false
+	if r.Method == "*" {
+		method = "GET"
+		star = true
+	}
+
+	args := make(map[string]string, len(argValues))
+	for k := range argValues {
+		args[k] = argValues.Get(k)
+	}
+
+	return &ActionDefinition{
+		Url:    url,
+		Method: method,
+		Star:   star,
+		Args:   args,
+		Host:   host,
+	}, nil
 }
 
 func init() {
 	OnAppStart(func() {
 		MainRouter = NewRouter(path.Join(BasePath, "conf", "routes"))
+		MainRouter.Extensions = Config.BoolDefault("routes.extensions", false)
 		if MainWatcher != nil && Config.BoolDefault("watch.routes", true) {
 			MainWatcher.Listen(MainRouter, MainRouter.path)
 		} else {
@@ -402,16 +1134,49 @@ func init() {
 }
 
 func RouterFilter(c *Controller, fc []Filter) {
+	// Requests for mounted handlers skip the controller and action.
+	if h := findHandler(c.Request.URL.Path); h != nil {
+		h.serve(c, fc)
+		return
+	}
+
 	// Figure out the Controller/Action
 	var route *RouteMatch = MainRouter.Route(c.Request.Request)
 	if route == nil {
-		c.Result = c.NotFound("No matching route found")
+		// The path may be routed for other methods.  If so, answer OPTIONS
+		// requests with the allowed methods, and reject anything else.
+		allowed := MainRouter.AllowedMethods(c.Request.Request)
+		if allowed == nil {
+			c.Result = c.NotFound("No matching route found")
+			return
+		}
+		c.Response.Out.Header().Set("Allow", strings.Join(allowed, ", "))
+		if c.Request.Method == "OPTIONS" {
+			if routedPreflight(c) {
+				return
+			}
+			c.Result = c.RenderText("")
+			return
+		}
+		c.Response.Status = http.StatusMethodNotAllowed
+		c.Result = c.RenderError(&Error{
+			Title:       "Method Not Allowed",
+			Description: c.Request.Method + " is not allowed for " + c.Request.URL.Path,
+		})
 		return
 	}
 
-	// The route may want to explicitly return a 404.
-	if route.Action == "404" {
-		c.Result = c.NotFound("(intentionally)")
+	// The route may want to explicitly return a status or redirect.
+	if status, target, ok := parseStatusAction(route.Action); ok {
+		c.Response.Status = status
+		if target != "" {
+			c.Result = &RedirectToUrlResult{redirectUrl(target, route.Params, c.Request.URL.RawQuery)}
+			return
+		}
+		c.Result = c.RenderError(&Error{
+			Title:       http.StatusText(status),
+			Description: "(intentionally)",
+		})
 		return
 	}
 
@@ -421,6 +1186,11 @@ func RouterFilter(c *Controller, fc []Filter) {
 		return
 	}
 
+	// The URL extension takes precedence over the Accept header.
+	if route.Format != "" {
+		c.Request.Format = route.Format
+	}
+
 	// Add the route and fixed params to the Request Params.
 	c.Params.Route = route.Params
 
@@ -2,8 +2,13 @@ package revel
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -69,6 +74,28 @@ var routeTestCases = map[string]*Route{
 		Action:      ":controller.:action",
 		FixedParams: []string{},
 	},
+
+	"get /users/:id<int> Users.Show": &Route{
+		Method:      "GET",
+		Path:        "/users/:id",
+		Action:      "Users.Show",
+		FixedParams: []string{},
+	},
+
+	"get /files/{<[a-z0-9-]+>slug} Files.Show": &Route{
+		Method:      "GET",
+		Path:        "/files/:slug",
+		Action:      "Files.Show",
+		FixedParams: []string{},
+	},
+
+	"get :tenant.example.com/dashboard Tenants.Dashboard": &Route{
+		Method:      "GET",
+		Host:        ":tenant.example.com",
+		Path:        "/dashboard",
+		Action:      "Tenants.Dashboard",
+		FixedParams: []string{},
+	},
 }
 
 // Run the test cases above.
@@ -81,6 +108,7 @@ func TestComputeRoute(t *testing.T) {
 		}
 		actual := NewRoute(method, path, action, fixedArgs, "", 0)
 		eq(t, "Method", actual.Method, expected.Method)
+		eq(t, "Host", actual.Host, expected.Host)
 		eq(t, "Path", actual.Path, expected.Path)
 		eq(t, "Action", actual.Action, expected.Action)
 		if t.Failed() {
@@ -204,7 +232,7 @@ var routeMatchTestCases = map[*http.Request]*RouteMatch{
 func TestRouteMatches(t *testing.T) {
 	BasePath = "/BasePath"
 	router := NewRouter("")
-	router.Routes, _ = parseRoutes("", TEST_ROUTES, false)
+	router.Routes, _ = parseRoutes("", "", TEST_ROUTES, false)
 	router.updateTree()
 	for req, expected := range routeMatchTestCases {
 		t.Log("Routing:", req.Method, req.URL)
@@ -225,6 +253,256 @@ func TestRouteMatches(t *testing.T) {
 	}
 }
 
+func TestAllowedMethods(t *testing.T) {
+	router := NewRouter("")
+	router.Routes, _ = parseRoutes("", "", `
+GET   /app/:id/       Application.Show
+POST  /app/:id        Application.Save
+PATCH /app/:id/       Application.Update
+GET   /favicon.ico    404
+`, false)
+	router.updateTree()
+
+	allowed := router.AllowedMethods(&http.Request{Method: "DELETE", URL: &url.URL{Path: "/app/123"}})
+	eq(t, "Allowed", strings.Join(allowed, ", "), "GET, HEAD, POST, PATCH, OPTIONS")
+
+	allowed = router.AllowedMethods(&http.Request{Method: "DELETE", URL: &url.URL{Path: "/favicon.ico"}})
+	eq(t, "Allowed", len(allowed), 0)
+
+	allowed = router.AllowedMethods(&http.Request{Method: "DELETE", URL: &url.URL{Path: "/not/routed/at/all"}})
+	eq(t, "Allowed", len(allowed), 0)
+}
+
+// Status responses and redirects
+
+func TestStatusRoutes(t *testing.T) {
+	router := NewRouter("")
+	router.Routes, _ = parseRoutes("", "", `
+GET   /old/:id             301:/new/path/:id
+GET   /docs/*filepath      302:https://docs.example.com/*filepath
+GET   /search              303:/find?q=all
+GET   /retired             410
+`, false)
+	router.updateTree()
+
+	for _, route := range router.Routes {
+		if err := validateRoute(route); err != nil {
+			t.Errorf("Route %s failed validation: %s", route.Action, err)
+		}
+		eq(t, "ControllerName", route.ControllerName, "")
+	}
+
+	match := router.Route(&http.Request{Method: "GET", URL: &url.URL{Path: "/old/a b"}})
+	eq(t, "Action", match.Action, "301:/new/path/:id")
+	eq(t, "Redirect", redirectUrl("/new/path/:id", match.Params, "page=2"), "/new/path/a%20b?page=2")
+
+	match = router.Route(&http.Request{Method: "GET", URL: &url.URL{Path: "/docs/guide/routing.html"}})
+	_, target, _ := parseStatusAction(match.Action)
+	eq(t, "Redirect", redirectUrl(target, match.Params, ""), "https://docs.example.com/guide/routing.html")
+
+	match = router.Route(&http.Request{Method: "GET", URL: &url.URL{Path: "/search"}})
+	_, target, _ = parseStatusAction(match.Action)
+	eq(t, "Redirect", redirectUrl(target, match.Params, "q=x"), "/find?q=all")
+
+	match = router.Route(&http.Request{Method: "GET", URL: &url.URL{Path: "/retired"}})
+	status, target, ok := parseStatusAction(match.Action)
+	eq(t, "Status", status, 410)
+	eq(t, "Target", target, "")
+	eq(t, "Status action", ok, true)
+
+	// Redirects are allowed methods, while explicit errors are not.
+	allowed := router.AllowedMethods(&http.Request{Method: "POST", URL: &url.URL{Path: "/old/1"}})
+	eq(t, "Allowed", strings.Join(allowed, ", "), "GET, HEAD, OPTIONS")
+	allowed = router.AllowedMethods(&http.Request{Method: "POST", URL: &url.URL{Path: "/retired"}})
+	eq(t, "Allowed", len(allowed), 0)
+
+	// Status routes are not reversed.
+	if _, err := router.ReverseValues("301.new", nil); err == nil {
+		t.Error("Expected no route for a status action")
+	}
+}
+
+func TestInvalidStatusRoutes(t *testing.T) {
+	for _, action := range []string{"301", "410:/gone", "299", "599"} {
+		if err := validateRoute(NewRoute("GET", "/", action, "", "", 0)); err == nil {
+			t.Errorf("Expected an error for action %s", action)
+		}
+	}
+}
+
+// Format extensions
+
+func TestExtensionRoutes(t *testing.T) {
+	router := NewRouter("")
+	router.Routes, _ = parseRoutes("", "", `
+GET   /hotels/:id          Hotels.Show
+GET   /robots.txt          Static.Serve("public/robots.txt")
+GET   /public/*filepath    Static.Serve("public")
+`, false)
+	router.updateTree()
+
+	for _, extensions := range []bool{false, true} {
+		router.Extensions = extensions
+		match := router.Route(&http.Request{Method: "GET", URL: &url.URL{Path: "/hotels/1.json"}})
+		if extensions {
+			eq(t, "id", match.Params["id"][0], "1")
+			eq(t, "Format", match.Format, "json")
+		} else {
+			eq(t, "id", match.Params["id"][0], "1.json")
+			eq(t, "Format", match.Format, "")
+		}
+	}
+
+	// Unrecognized extensions are left alone.
+	match := router.Route(&http.Request{Method: "GET", URL: &url.URL{Path: "/hotels/1.csv"}})
+	eq(t, "id", match.Params["id"][0], "1.csv")
+	eq(t, "Format", match.Format, "")
+
+	// Paths that only match with the extension, or that end in a star arg, keep it.
+	match = router.Route(&http.Request{Method: "GET", URL: &url.URL{Path: "/robots.txt"}})
+	eq(t, "Action", match.MethodName, "Serve")
+	eq(t, "Format", match.Format, "")
+	match = router.Route(&http.Request{Method: "GET", URL: &url.URL{Path: "/public/js/data.json"}})
+	eq(t, "filepath", match.Params["filepath"][0], "js/data.json")
+	eq(t, "Format", match.Format, "")
+}
+
+func TestReverseWithFormat(t *testing.T) {
+	for url, expected := range map[string]string{
+		"/hotels/1":          "/hotels/1.json",
+		"/hotels/":           "/hotels.json",
+		"/hotels?page=2":     "/hotels.json?page=2",
+		"//api.example.com/": "//api.example.com/.json",
+		"/":                  "/.json",
+	} {
+		actionDef := &ActionDefinition{Url: url}
+		eq(t, "Url", actionDef.WithFormat("json").Url, expected)
+		eq(t, "Original Url", actionDef.Url, url)
+	}
+}
+
+// Resource declarations
+
+func TestResourceRoutes(t *testing.T) {
+	routes, err := parseRoutes("", "", `
+RESOURCE /hotels Hotels
+resource /bookings/ Bookings only:Index,Show
+RESOURCE api.example.com/users Users except:new,edit
+`, false)
+	if err != nil {
+		t.Fatal("Failed to parse resource routes:", err)
+	}
+
+	expected := []string{
+		"GET /hotels Hotels.Index",
+		"GET /hotels/new Hotels.New",
+		"POST /hotels Hotels.Create",
+		"GET /hotels/:id Hotels.Show",
+		"GET /hotels/:id/edit Hotels.Edit",
+		"PUT /hotels/:id Hotels.Update",
+		"PATCH /hotels/:id Hotels.Update",
+		"DELETE /hotels/:id Hotels.Destroy",
+		"GET /bookings Bookings.Index",
+		"GET /bookings/:id Bookings.Show",
+		"GET api.example.com/users Users.Index",
+		"POST api.example.com/users Users.Create",
+		"GET api.example.com/users/:id Users.Show",
+		"PUT api.example.com/users/:id Users.Update",
+		"PATCH api.example.com/users/:id Users.Update",
+		"DELETE api.example.com/users/:id Users.Destroy",
+	}
+	if !eq(t, "len(routes)", len(routes), len(expected)) {
+		return
+	}
+	for i, route := range routes {
+		eq(t, "Route", route.Method+" "+route.Host+route.Path+" "+route.Action, expected[i])
+	}
+
+	router := NewRouter("")
+	router.Routes = routes
+	router.updateTree()
+	actual := router.Route(&http.Request{Method: "GET", URL: &url.URL{Path: "/hotels/new"}})
+	eq(t, "MethodName", actual.MethodName, "New")
+	actual = router.Route(&http.Request{Method: "GET", URL: &url.URL{Path: "/hotels/3"}})
+	eq(t, "MethodName", actual.MethodName, "Show")
+	eq(t, "Url", router.Reverse("Hotels.Edit", map[string]string{"id": "3"}).Url, "/hotels/3/edit")
+	eq(t, "Method", router.Reverse("Hotels.Destroy", map[string]string{"id": "3"}).Method, "DELETE")
+}
+
+func TestInvalidResourceRoutes(t *testing.T) {
+	for _, line := range []string{
+		"RESOURCE /hotels",
+		"RESOURCE /hotels Hotels only:Index,Frobnicate",
+		"RESOURCE /hotels Hotels sometimes:Index",
+	} {
+		if _, err := parseRoutes("", "", line, false); err == nil {
+			t.Error("Expected an error for:", line)
+		}
+	}
+}
+
+// Prefix blocks
+
+func TestPrefixRoutes(t *testing.T) {
+	// Set up a module with its own routes file.
+	modulePath, err := ioutil.TempDir("", "revel-module")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(modulePath)
+	os.Mkdir(filepath.Join(modulePath, "conf"), 0755)
+	ioutil.WriteFile(filepath.Join(modulePath, "conf", "routes"),
+		[]byte("GET /status Jobs.Status\n"), 0644)
+	Modules = append(Modules, Module{Name: "prefixtest", Path: modulePath})
+	defer func() { Modules = Modules[:len(Modules)-1] }()
+
+	routes, routeErr := parseRoutes("", "", `
+PREFIX /api {
+	GET    /                 Api.Index
+	PREFIX /v1/ {
+		GET    /users/:id    Users.Show
+		RESOURCE /hotels Hotels only:Index
+		module:prefixtest
+	}
+	GET    /ping             Api.Ping
+}
+module:prefixtest /admin/jobs
+GET    /                     Application.Index
+`, false)
+	if routeErr != nil {
+		t.Fatal("Failed to parse prefixed routes:", routeErr)
+	}
+
+	expected := []string{
+		"GET /api/ Api.Index",
+		"GET /api/v1/users/:id Users.Show",
+		"GET /api/v1/hotels Hotels.Index",
+		"GET /api/v1/status Jobs.Status",
+		"GET /api/ping Api.Ping",
+		"GET /admin/jobs/status Jobs.Status",
+		"GET / Application.Index",
+	}
+	if !eq(t, "len(routes)", len(routes), len(expected)) {
+		return
+	}
+	for i, route := range routes {
+		eq(t, "Route", route.Method+" "+route.Path+" "+route.Action, expected[i])
+	}
+}
+
+func TestInvalidPrefixRoutes(t *testing.T) {
+	for _, content := range []string{
+		"PREFIX /api {\nGET / Api.Index",
+		"GET / Api.Index\n}",
+		"PREFIX api {\n}",
+		"PREFIX /api\n}",
+	} {
+		if _, err := parseRoutes("", "", content, false); err == nil {
+			t.Error("Expected an error for:", content)
+		}
+	}
+}
+
 // Reverse Routing
 
 type ReverseRouteArgs struct {
@@ -276,7 +554,7 @@ var reverseRoutingTestCases = map[*ReverseRouteArgs]*ActionDefinition{
 
 func TestReverseRouting(t *testing.T) {
 	router := NewRouter("")
-	router.Routes, _ = parseRoutes("", TEST_ROUTES, false)
+	router.Routes, _ = parseRoutes("", "", TEST_ROUTES, false)
 	for routeArgs, expected := range reverseRoutingTestCases {
 		actual := router.Reverse(routeArgs.action, routeArgs.args)
 		if !eq(t, "Found route", actual != nil, expected != nil) {
@@ -289,9 +567,277 @@ func TestReverseRouting(t *testing.T) {
 	}
 }
 
+// Host routing
+
+const TEST_HOST_ROUTES = `
+GET   api.example.com/users           Api.Users
+GET   :tenant.example.com/            Tenants.Index
+GET   :tenant.example.com/users/:id   Tenants.ShowUser
+GET   /users                          Application.Users
+`
+
+var hostRouteMatchTestCases = map[*http.Request]*RouteMatch{
+	&http.Request{
+		Method: "GET",
+		Host:   "api.example.com:9000",
+		URL:    &url.URL{Path: "/users"},
+	}: &RouteMatch{
+		ControllerName: "Api",
+		MethodName:     "Users",
+		Params:         map[string][]string{},
+	},
+
+	&http.Request{
+		Method: "GET",
+		Host:   "acme.example.com",
+		URL:    &url.URL{Path: "/users/123"},
+	}: &RouteMatch{
+		ControllerName: "Tenants",
+		MethodName:     "ShowUser",
+		Params:         map[string][]string{"tenant": {"acme"}, "id": {"123"}},
+	},
+
+	&http.Request{
+		Method: "GET",
+		Host:   "ACME.example.com",
+		URL:    &url.URL{Path: "/"},
+	}: &RouteMatch{
+		ControllerName: "Tenants",
+		MethodName:     "Index",
+		Params:         map[string][]string{"tenant": {"acme"}},
+	},
+
+	&http.Request{
+		Method: "GET",
+		Host:   "acme.example.com",
+		URL:    &url.URL{Path: "/users"},
+	}: &RouteMatch{
+		ControllerName: "Application",
+		MethodName:     "Users",
+		Params:         map[string][]string{},
+	},
+
+	&http.Request{
+		Method: "GET",
+		Host:   "example.org",
+		URL:    &url.URL{Path: "/users"},
+	}: &RouteMatch{
+		ControllerName: "Application",
+		MethodName:     "Users",
+		Params:         map[string][]string{},
+	},
+
+	&http.Request{
+		Method: "GET",
+		Host:   "example.org",
+		URL:    &url.URL{Path: "/users/123"},
+	}: nil,
+}
+
+func TestHostRouteMatches(t *testing.T) {
+	router := NewRouter("")
+	router.Routes, _ = parseRoutes("", "", TEST_HOST_ROUTES, false)
+	router.updateTree()
+	for req, expected := range hostRouteMatchTestCases {
+		t.Log("Routing:", req.Method, req.Host, req.URL)
+		actual := router.Route(req)
+		if !eq(t, "Found route", actual != nil, expected != nil) || expected == nil {
+			continue
+		}
+		eq(t, "ControllerName", actual.ControllerName, expected.ControllerName)
+		eq(t, "MethodName", actual.MethodName, expected.MethodName)
+		eq(t, "len(Params)", len(actual.Params), len(expected.Params))
+		for key, actualValue := range actual.Params {
+			eq(t, "Params", actualValue[0], expected.Params[key][0])
+		}
+	}
+}
+
+func TestHostReverseRouting(t *testing.T) {
+	router := NewRouter("")
+	router.Routes, _ = parseRoutes("", "", TEST_HOST_ROUTES, false)
+
+	actual := router.Reverse("Tenants.ShowUser", map[string]string{"tenant": "acme", "id": "123"})
+	eq(t, "Url", actual.Url, "//acme.example.com/users/123")
+	eq(t, "Host", actual.Host, "acme.example.com")
+
+	actual = router.Reverse("Application.Users", map[string]string{})
+	eq(t, "Url", actual.Url, "/users")
+	eq(t, "Host", actual.Host, "")
+}
+
+func TestInvalidHostPattern(t *testing.T) {
+	for _, path := range []string{".example.com/x", "a..example.com/x", "example.com./x", ":.example.com/x"} {
+		if validateRoute(NewRoute("GET", path, "Application.Index", "", "", 0)) == nil {
+			t.Error("Expected an error for the host pattern of", path)
+		}
+	}
+}
+
+// Constrained route arguments
+
+const TEST_CONSTRAINED_ROUTES = `
+GET   /users/:id<int>                  Users.Show
+GET   /users/:name                     Users.ShowByName
+GET   /users/new                       Users.New
+GET   /files/{<[a-z0-9-]+>slug}         Files.Show
+GET   /posts/:year<uint>/:slug<[a-z]+>  Posts.Show
+*     /:controller/:action             :controller.:action
+`
+
+var constrainedRouteMatchTestCases = map[string]*RouteMatch{
+	"/users/123": &RouteMatch{
+		ControllerName: "Users",
+		MethodName:     "Show",
+		Params:         map[string][]string{"id": {"123"}},
+	},
+
+	"/users/bob": &RouteMatch{
+		ControllerName: "Users",
+		MethodName:     "ShowByName",
+		Params:         map[string][]string{"name": {"bob"}},
+	},
+
+	"/users/new": &RouteMatch{
+		ControllerName: "Users",
+		MethodName:     "ShowByName",
+		Params:         map[string][]string{"name": {"new"}},
+	},
+
+	"/files/my-file-1": &RouteMatch{
+		ControllerName: "Files",
+		MethodName:     "Show",
+		Params:         map[string][]string{"slug": {"my-file-1"}},
+	},
+
+	"/files/My_File": &RouteMatch{
+		ControllerName: "files",
+		MethodName:     "My_File",
+		Params: map[string][]string{
+			"METHOD":     {"GET"},
+			"controller": {"files"},
+			"action":     {"My_File"},
+		},
+	},
+
+	"/posts/2013/hello": &RouteMatch{
+		ControllerName: "Posts",
+		MethodName:     "Show",
+		Params:         map[string][]string{"year": {"2013"}, "slug": {"hello"}},
+	},
+
+	"/posts/-1/hello":  nil,
+	"/posts/2013/1234": nil,
+}
+
+func TestConstrainedRouteMatches(t *testing.T) {
+	router := NewRouter("")
+	router.Routes, _ = parseRoutes("", "", TEST_CONSTRAINED_ROUTES, false)
+	router.updateTree()
+	for path, expected := range constrainedRouteMatchTestCases {
+		t.Log("Routing:", path)
+		actual := router.Route(&http.Request{Method: "GET", URL: &url.URL{Path: path}})
+		if !eq(t, "Found route", actual != nil, expected != nil) || expected == nil {
+			continue
+		}
+		eq(t, "ControllerName", actual.ControllerName, expected.ControllerName)
+		eq(t, "MethodName", actual.MethodName, expected.MethodName)
+		eq(t, "len(Params)", len(actual.Params), len(expected.Params))
+		for key, actualValue := range actual.Params {
+			eq(t, "Params", actualValue[0], expected.Params[key][0])
+		}
+	}
+}
+
+func TestInvalidRouteConstraint(t *testing.T) {
+	route := NewRoute("GET", "/users/:id<[0-9>", "Users.Show", "", "", 0)
+	if validateRoute(route) == nil {
+		t.Error("Expected an error for an invalid constraint")
+	}
+}
+
+func TestReverseValues(t *testing.T) {
+	router := NewRouter("")
+	router.Routes, _ = parseRoutes("", "", `
+GET   /app/:id/           Application.Show
+GET   /public/*filepath   Static.Serve("public")
+GET   /users/:id<int>     Users.Show
+GET   /users              Users.Show
+GET   /tags/:tag          Tags.Show
+`, false)
+
+	// Path args are escaped; query args may have multiple values.
+	actual, err := router.ReverseValues("Tags.Show", url.Values{
+		"tag":  {"a/b c"},
+		"sort": {"name", "date"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eq(t, "Url", actual.Url, "/tags/a%2Fb%20c?sort=name&sort=date")
+
+	// Star args keep their slashes.
+	actual, err = router.ReverseValues("Static.Serve", url.Values{"filepath": {"css/my style.css"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eq(t, "Url", actual.Url, "/public/css/my%20style.css")
+
+	// Missing args result in an error.
+	_, err = router.ReverseValues("Application.Show", url.Values{})
+	if err == nil {
+		t.Error("Expected an error for a missing route arg")
+	}
+
+	// Routes whose constraints are not satisfied are skipped.
+	actual, err = router.ReverseValues("Users.Show", url.Values{"id": {"123"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eq(t, "Url", actual.Url, "/users/123")
+	actual, err = router.ReverseValues("Users.Show", url.Values{"id": {"bob"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eq(t, "Url", actual.Url, "/users?id=bob")
+
+	// Unknown actions result in an error.
+	if _, err = router.ReverseValues("Nope.Nope", nil); err == nil {
+		t.Error("Expected an error for an unknown action")
+	}
+}
+
+func TestAbsoluteUrl(t *testing.T) {
+	HttpScheme, HttpHost = "https", "www.example.com"
+	actionDef := &ActionDefinition{Url: "/app/123"}
+	eq(t, "AbsoluteUrl", actionDef.AbsoluteUrl(), "https://www.example.com/app/123")
+	actionDef = &ActionDefinition{Url: "//acme.example.com/app/123"}
+	eq(t, "AbsoluteUrl", actionDef.AbsoluteUrl(), "https://acme.example.com/app/123")
+}
+
+func TestReverseUrl(t *testing.T) {
+	startFakeBookingApp()
+
+	actual, err := ReverseUrl("Hotels.Show", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eq(t, "Url", actual, "/hotels/3")
+
+	actual, err = ReverseUrl("Hotels.Show", 3, url.Values{"tab": {"map"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eq(t, "Url", actual, "/hotels/3?tab=map")
+
+	if _, err = ReverseUrl("Hotels.Show", 3, 4); err == nil {
+		t.Error("Expected an error for too many arguments")
+	}
+}
+
 func BenchmarkRouter(b *testing.B) {
 	router := NewRouter("")
-	router.Routes, _ = parseRoutes("", TEST_ROUTES, false)
+	router.Routes, _ = parseRoutes("", "", TEST_ROUTES, false)
 	router.updateTree()
 	b.ResetTimer()
 	for i := 0; i < b.N/len(routeMatchTestCases); i++ {
@@ -383,3 +929,57 @@ func eq(t *testing.T, name string, a, b interface{}) bool {
 	}
 	return true
 }
+
+// testRequest returns a request with the given headers, as name, value pairs.
+// Headers with empty values are left out.
+func testRequest(method, url string, headers ...string) *http.Request {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		panic(err)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		if headers[i+1] != "" {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+	}
+	return req
+}
+
+// setConfig sets the config options, and re-runs the startup hooks that read
+// them.  The returned func restores the options, and should be deferred.
+func setConfig(options map[string]string) (restore func()) {
+	type option struct {
+		value string
+		found bool
+	}
+	config, router := Config, MainRouter
+	previous := make(map[string]option, len(options))
+	for name, value := range options {
+		old, found := config.String(name)
+		previous[name] = option{old, found}
+		config.SetOption(name, value)
+	}
+
+	// Keep the test's router, rather than that of the routes file.
+	runStartupHooks()
+	MainRouter = router
+
+	return func() {
+		for name, old := range previous {
+			if old.found {
+				config.SetOption(name, old.value)
+			} else {
+				config.Raw().RemoveOption(config.section, name)
+			}
+		}
+		runStartupHooks()
+		MainRouter = router
+	}
+}
+
+// testController returns a controller for the request, and the recorder of its
+// response.
+func testController(req *http.Request) (*Controller, *httptest.ResponseRecorder) {
+	resp := httptest.NewRecorder()
+	return NewController(NewRequest(req), NewResponse(resp)), resp
+}
@@ -0,0 +1,88 @@
+package revel
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"html/template"
+	"strings"
+)
+
+// The security headers, from app.conf.
+var (
+	secureHsts               string
+	secureFrameOptions       string
+	secureContentTypeOptions string
+	secureReferrerPolicy     string
+	secureCsp                string
+)
+
+func init() {
+	OnAppStart(func() {
+		secureHsts = hstsHeaderValue()
+		secureFrameOptions = Config.StringDefault("http.frameoptions", "SAMEORIGIN")
+		secureContentTypeOptions = Config.StringDefault("http.contenttypeoptions", "nosniff")
+		secureReferrerPolicy = Config.StringDefault("http.referrerpolicy", "strict-origin-when-cross-origin")
+		secureCsp = Config.StringDefault("http.csp", "")
+	})
+
+	// Emits the nonce attribute for an inline script or style, e.g.
+	//   <script {{nonce .}}>
+	TemplateFuncs["nonce"] = func(renderArgs map[string]interface{}) template.HTMLAttr {
+		nonce, ok := renderArgs["CSPNonce"].(string)
+		if !ok {
+			WARN.Println("Called 'nonce' without the SecureHeadersFilter.")
+			return template.HTMLAttr("")
+		}
+		return template.HTMLAttr(`nonce="` + nonce + `"`)
+	}
+}
+
+// SecureHeadersFilter sets the headers that guard browsers against common
+// attacks, as configured in app.conf.  For example (showing the defaults):
+//   http.frameoptions = SAMEORIGIN
+//   http.contenttypeoptions = nosniff
+//   http.referrerpolicy = strict-origin-when-cross-origin
+//   http.csp = default-src 'self'; script-src 'self' 'nonce-{nonce}'
+//
+// An empty value leaves the header out.  HTTPS responses (including those
+// forwarded by a proxy) also carry Strict-Transport-Security, as configured by
+// http.hsts.*.
+//
+// Each occurrence of {nonce} in the Content-Security-Policy is replaced with a
+// random value for the request.  It is available to templates as .CSPNonce,
+// and inline scripts that carry it (using {{nonce .}}) are allowed to run.
+func SecureHeadersFilter(c *Controller, fc []Filter) {
+	header := c.Response.Out.Header()
+	if secureHsts != "" && (c.Request.TLS != nil || c.Request.Header.Get("X-Forwarded-Proto") == "https") {
+		header.Set("Strict-Transport-Security", secureHsts)
+	}
+	if secureFrameOptions != "" {
+		header.Set("X-Frame-Options", secureFrameOptions)
+	}
+	if secureContentTypeOptions != "" {
+		header.Set("X-Content-Type-Options", secureContentTypeOptions)
+	}
+	if secureReferrerPolicy != "" {
+		header.Set("Referrer-Policy", secureReferrerPolicy)
+	}
+	if secureCsp != "" {
+		csp := secureCsp
+		if strings.Contains(csp, "{nonce}") {
+			nonce := newCspNonce()
+			c.RenderArgs["CSPNonce"] = nonce
+			csp = strings.Replace(csp, "{nonce}", nonce, -1)
+		}
+		header.Set("Content-Security-Policy", csp)
+	}
+
+	fc[0](c, fc[1:])
+}
+
+// newCspNonce returns a new random nonce.
+func newCspNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
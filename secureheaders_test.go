@@ -0,0 +1,54 @@
+package revel
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSecureHeadersFilter(t *testing.T) {
+	startFakeBookingApp()
+	defer setConfig(map[string]string{
+		"http.hsts.maxage":    "24h",
+		"http.referrerpolicy": "",
+		"http.csp":            "default-src 'self'; script-src 'self' 'nonce-{nonce}'",
+	})()
+
+	serve := func(proto string) (*Controller, http.Header) {
+		c, resp := testController(testRequest("GET", "/hotels/3", "X-Forwarded-Proto", proto))
+		SecureHeadersFilter(c, NilChain)
+		return c, resp.Header()
+	}
+
+	c, header := serve("https")
+	eq(t, "HSTS", header.Get("Strict-Transport-Security"), "max-age=86400")
+	eq(t, "X-Frame-Options", header.Get("X-Frame-Options"), "SAMEORIGIN")
+	eq(t, "X-Content-Type-Options", header.Get("X-Content-Type-Options"), "nosniff")
+	eq(t, "Referrer-Policy", header.Get("Referrer-Policy"), "")
+
+	nonce, _ := c.RenderArgs["CSPNonce"].(string)
+	if nonce == "" {
+		t.Fatal("Expected a nonce in the render args")
+	}
+	eq(t, "CSP", header.Get("Content-Security-Policy"),
+		"default-src 'self'; script-src 'self' 'nonce-"+nonce+"'")
+
+	// Each request gets its own nonce, and plain HTTP responses no HSTS.
+	other, header := serve("http")
+	if other.RenderArgs["CSPNonce"] == nonce {
+		t.Error("Expected a new nonce for each request")
+	}
+	eq(t, "HSTS over HTTP", header.Get("Strict-Transport-Security"), "")
+
+	// The template func emits the nonce attribute.
+	tmpl := template.Must(template.New("").Funcs(TemplateFuncs).Parse(`<script {{nonce .}}>alert(1)</script>`))
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, c.RenderArgs); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), `<script nonce="`+nonce+`">`) {
+		t.Errorf("Expected the nonce attribute, got %s", out.String())
+	}
+}
@@ -2,8 +2,16 @@ package revel
 
 import (
 	"code.google.com/p/go.net/websocket"
+	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -12,11 +20,18 @@ var (
 	MainTemplateLoader *TemplateLoader
 	MainWatcher        *Watcher
 	Server             *http.Server
+	RedirectServer     *http.Server // Redirects plain HTTP to HTTPS, if configured.
+
+	hstsHeader string // The Strict-Transport-Security header for HTTPS responses.
 )
 
 // This method handles all requests.  It dispatches to handleInternal after
 // handling / adapting websocket connections.
 func handle(w http.ResponseWriter, r *http.Request) {
+	if hstsHeader != "" && r.TLS != nil {
+		w.Header().Set("Strict-Transport-Security", hstsHeader)
+	}
+
 	upgrade := r.Header.Get("Upgrade")
 	if upgrade == "websocket" || upgrade == "Websocket" {
 		websocket.Handler(func(ws *websocket.Conn) {
@@ -29,12 +44,21 @@ func handle(w http.ResponseWriter, r *http.Request) {
 }
 
 func handleInternal(w http.ResponseWriter, r *http.Request, ws *websocket.Conn) {
+	// Tag the request with an ID, carried by its context.  The context is
+	// cancelled when the client disconnects, or once the request is handled.
+	id := requestId(r)
+	w.Header().Set(RequestIdHeader, id)
+	ctx, cancel := context.WithCancel(context.WithValue(r.Context(), requestIdKey{}, id))
+	defer cancel()
+
 	var (
-		req  = NewRequest(r)
+		req  = NewRequest(r.WithContext(ctx))
 		resp = NewResponse(w)
 		c    = NewController(req, resp)
 	)
 	req.Websocket = ws
+	c.RequestId = id
+	c.RenderArgs["RequestId"] = id
 
 	Filters[0](c, Filters[1:])
 	if c.Result != nil {
@@ -42,20 +66,24 @@ func handleInternal(w http.ResponseWriter, r *http.Request, ws *websocket.Conn)
 	}
 }
 
-// Run the server.
-// This is called from the generated main file.
-// If port is non-zero, use that.  Else, read the port from app.conf.
-func Run(port int) {
-	address := HttpAddr
-	if port == 0 {
-		port = HttpPort
-	}
-	// If the port equals zero, it means do not append port to the address.
-	// It can use unix socket or something else.
-	if port != 0 {
-		address = fmt.Sprintf("%s:%d", address, port)
-	}
+// NewHandler sets up the app and returns an http.Handler that serves it.  This
+// allows the app to be mounted within another server, wrapped in middleware, or
+// tested with httptest, without calling Run.
+//
+// Setting up the app loads its templates, configures the filters and runs the
+// OnAppStart hooks (which load the routes).  Revel must have been initialized,
+// and the app's controllers registered, as is done by the generated main file.
+// The app is set up only once, so every call returns an equivalent handler.
+func NewHandler() http.Handler {
+	CheckInit()
+	setupOnce.Do(setup)
+	return http.HandlerFunc(handle)
+}
 
+var setupOnce sync.Once
+
+// setup prepares the app to handle requests.
+func setup() {
 	MainTemplateLoader = NewTemplateLoader(TemplatePaths)
 
 	// The "watch" config variable can turn on and off all watching.
@@ -73,24 +101,177 @@ func Run(port int) {
 		MainTemplateLoader.Refresh()
 	}
 
-	Server = &http.Server{
-		Addr:    address,
-		Handler: http.HandlerFunc(handle),
+	if HttpSsl {
+		hstsHeader = hstsHeaderValue()
 	}
 
 	runStartupHooks()
+}
+
+// Run the server.
+// This is called from the generated main file.
+// If port is non-zero, use that.  Else, read the port from app.conf.
+//
+// To listen on a unix socket instead, give its path as the address, in which
+// case the port is not used:
+//   http.addr = /var/run/myapp.sock
+func Run(port int) {
+	if port == 0 {
+		port = HttpPort
+	}
+
+	Server = &http.Server{
+		Addr:           serverAddress(HttpAddr, port),
+		Handler:        NewHandler(),
+		ReadTimeout:    Config.DurationDefault("http.timeout.read", 0),
+		WriteTimeout:   Config.DurationDefault("http.timeout.write", 0),
+		IdleTimeout:    Config.DurationDefault("http.timeout.idle", 0),
+		MaxHeaderBytes: Config.IntDefault("http.maxheaderbytes", 0),
+	}
+
+	// When serving HTTPS, plain HTTP may be served on another port, redirecting
+	// to HTTPS.  Requests for the exempt paths (e.g. health checks) are handled
+	// as usual.
+	if redirectPort := Config.IntDefault("http.redirect.port", 0); HttpSsl && redirectPort != 0 {
+		redirectAddr := HttpAddr
+		if strings.Contains(redirectAddr, "/") {
+			redirectAddr = "" // The socket is taken by the server.
+		}
+		RedirectServer = &http.Server{
+			Addr:           serverAddress(redirectAddr, redirectPort),
+			Handler:        redirectToHttps(splitList(Config.StringDefault("http.redirect.exempt", ""))),
+			ReadTimeout:    Server.ReadTimeout,
+			WriteTimeout:   Server.WriteTimeout,
+			IdleTimeout:    Server.IdleTimeout,
+			MaxHeaderBytes: Server.MaxHeaderBytes,
+		}
+	}
+	if HttpSsl {
+		var err error
+		if Server.TLSConfig, err = newTlsConfig(); err != nil {
+			ERROR.Fatalln("Failed to configure TLS:", err)
+		}
+	}
+
+	listener, err := listen(Server.Addr)
+	if err != nil {
+		ERROR.Fatalln("Failed to listen:", err)
+	}
 
 	go func() {
 		time.Sleep(100 * time.Millisecond)
-		fmt.Printf("Listening on port %d...\n", port)
+		fmt.Printf("Listening on %s...\n", listener.Addr())
 	}()
 
-	if HttpSsl {
-		ERROR.Fatalln("Failed to listen:",
-			Server.ListenAndServeTLS(HttpSslCert, HttpSslKey))
-	} else {
-		ERROR.Fatalln("Failed to listen:", Server.ListenAndServe())
+	// Serve until a server fails or we are asked to stop.
+	listenErr := make(chan error, 2)
+	go func() {
+		if HttpSsl {
+			// The certificate is provided by the TLS config.
+			listenErr <- Server.ServeTLS(listener, "", "")
+		} else {
+			listenErr <- Server.Serve(listener)
+		}
+	}()
+	if RedirectServer != nil {
+		go func() {
+			listenErr <- RedirectServer.ListenAndServe()
+		}()
 	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	select {
+	case err := <-listenErr:
+		ERROR.Fatalln("Failed to listen:", err)
+	case sig := <-stop:
+		INFO.Println("Received", sig, "- shutting down")
+	}
+	signal.Stop(stop)
+
+	shutdown(Config.DurationDefault("http.timeout.shutdown", 10*time.Second))
+}
+
+// shutdown stops the server from accepting connections and waits for the
+// in-flight requests to complete, for up to the given timeout.  Connections
+// still active after the timeout are closed.  The OnAppStop hooks are then run.
+func shutdown(timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if RedirectServer != nil {
+		go RedirectServer.Shutdown(ctx)
+	}
+	if err := Server.Shutdown(ctx); err != nil {
+		WARN.Println("Requests still in progress after", timeout, "- closing connections:", err)
+		Server.Close()
+	}
+	runShutdownHooks()
+}
+
+// serverAddress returns the address to listen on.  The port is left off if it
+// is zero, or if the address is a path (e.g. /var/run/app.sock), which is a
+// unix socket.
+func serverAddress(addr string, port int) string {
+	if port == 0 || strings.Contains(addr, "/") {
+		return addr
+	}
+	return fmt.Sprintf("%s:%d", addr, port)
+}
+
+// listen listens on the given address.  An address that is a path is a unix
+// socket, which is replaced if it already exists.
+func listen(address string) (net.Listener, error) {
+	if !strings.Contains(address, "/") {
+		if address == "" {
+			address = ":http"
+			if HttpSsl {
+				address = ":https"
+			}
+		}
+		return net.Listen("tcp", address)
+	}
+	if info, err := os.Stat(address); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(address)
+	}
+	return net.Listen("unix", address)
+}
+
+// redirectToHttps returns a handler that permanently redirects requests to the
+// same URL over HTTPS, except for those for the exempt paths, which are handled
+// as usual.
+func redirectToHttps(exempt []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, path := range exempt {
+			if strings.HasPrefix(r.URL.Path, path) {
+				handle(w, r)
+				return
+			}
+		}
+		host := requestHost(r)
+		if HttpPort != 0 && HttpPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(HttpPort))
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// hstsHeaderValue returns the Strict-Transport-Security header configured by
+// http.hsts.* in app.conf, or "" if HSTS is not enabled.
+func hstsHeaderValue() string {
+	maxAge := Config.DurationDefault("http.hsts.maxage", 0)
+	if maxAge <= 0 {
+		return ""
+	}
+	value := fmt.Sprintf("max-age=%d", int64(maxAge/time.Second))
+	if Config.BoolDefault("http.hsts.includesubdomains", false) {
+		value += "; includeSubDomains"
+	}
+	if Config.BoolDefault("http.hsts.preload", false) {
+		value += "; preload"
+	}
+	return value
 }
 
 func runStartupHooks() {
@@ -99,8 +280,33 @@ func runStartupHooks() {
 	}
 }
 
-var startupHooks []func()
+// runShutdownHooks runs the OnAppStop hooks in the reverse order of their
+// registration.  A panic in one hook is logged, and the remaining hooks run.
+func runShutdownHooks() {
+	for i := len(shutdownHooks) - 1; i >= 0; i-- {
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					ERROR.Println("Panic in app stop hook:", err)
+				}
+			}()
+			shutdownHooks[i]()
+		}()
+	}
+}
+
+var (
+	startupHooks  []func()
+	shutdownHooks []func()
+)
 
 func OnAppStart(f func()) {
 	startupHooks = append(startupHooks, f)
 }
+
+// OnAppStop registers a function to be run when the app shuts down, once the
+// in-flight requests have completed.  Hooks run in the reverse order of their
+// registration, so that a hook runs before those of the things it depends on.
+func OnAppStop(f func()) {
+	shutdownHooks = append(shutdownHooks, f)
+}
@@ -1,12 +1,16 @@
 package revel
 
 import (
+	"crypto/tls"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path"
 	"strings"
 	"testing"
+	"time"
 )
 
 // This tries to benchmark the usual request-serving pipeline to get an overall
@@ -86,6 +90,174 @@ func TestFakeServer(t *testing.T) {
 	resp.Body = nil
 }
 
+// Test that a routed path requested with the wrong method is rejected with a
+// 405, and that OPTIONS requests are answered from the routing table.
+func TestMethodNotAllowed(t *testing.T) {
+	startFakeBookingApp()
+
+	resp := httptest.NewRecorder()
+	handle(resp, deleteRequest)
+	if resp.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected a 405 response, got %d:\n%s", resp.Code, resp.Body)
+	}
+	if allow := resp.HeaderMap.Get("Allow"); allow != "GET, HEAD, OPTIONS" {
+		t.Errorf("Unexpected Allow header: %s", allow)
+	}
+
+	resp = httptest.NewRecorder()
+	handle(resp, optionsRequest)
+	if resp.Code != http.StatusOK {
+		t.Errorf("Expected a 200 response, got %d:\n%s", resp.Code, resp.Body)
+	}
+	if allow := resp.HeaderMap.Get("Allow"); allow != "GET, HEAD, OPTIONS" {
+		t.Errorf("Unexpected Allow header: %s", allow)
+	}
+}
+
+// Test that the app can be served through the handler returned by NewHandler.
+func TestNewHandler(t *testing.T) {
+	startFakeBookingApp()
+	handler := NewHandler()
+
+	// The fake app does not register every controller in the booking app's
+	// routes, so reload them without validation.
+	startFakeBookingApp()
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/hotels/3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "300 Main St.") {
+		t.Errorf("Failed to find hotel address in action response:\n%s", body)
+	}
+}
+
+// Test that shutdown waits for in-flight requests, and then runs the stop
+// hooks in reverse order.
+func TestShutdown(t *testing.T) {
+	defer func(server *http.Server, hooks []func()) {
+		Server, shutdownHooks = server, hooks
+	}(Server, shutdownHooks)
+
+	var stopped []string
+	shutdownHooks = nil
+	OnAppStop(func() { stopped = append(stopped, "first") })
+	OnAppStop(func() { panic("hook failed") })
+	OnAppStop(func() { stopped = append(stopped, "last") })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	Server = &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("done"))
+	})}
+	go Server.Serve(listener)
+
+	body := make(chan string)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+
+	<-started
+	shutdown(5 * time.Second)
+	if len(stopped) != 2 || stopped[0] != "last" || stopped[1] != "first" {
+		t.Errorf("Expected stop hooks to run in reverse order, got %v", stopped)
+	}
+	if b := <-body; b != "done" {
+		t.Errorf("Expected the in-flight request to complete, got %s", b)
+	}
+}
+
+// Test that plain HTTP requests are redirected to HTTPS, except for exempt paths.
+func TestRedirectToHttps(t *testing.T) {
+	startFakeBookingApp()
+	defer func(port int) { HttpPort = port }(HttpPort)
+	handler := redirectToHttps([]string{"/public/"})
+
+	for port, expected := range map[int]string{
+		443:  "https://www.example.com/hotels?page=2",
+		8443: "https://www.example.com:8443/hotels?page=2",
+	} {
+		HttpPort = port
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "http://www.example.com:8080/hotels?page=2", nil)
+		handler.ServeHTTP(resp, req)
+		eq(t, "Status", resp.Code, http.StatusMovedPermanently)
+		eq(t, "Location", resp.Header().Get("Location"), expected)
+	}
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, staticRequest)
+	eq(t, "Exempt status", resp.Code, http.StatusOK)
+}
+
+// Test that the HSTS header is sent on HTTPS responses only.
+func TestHsts(t *testing.T) {
+	startFakeBookingApp()
+	defer func() { hstsHeader = "" }()
+	hstsHeader = "max-age=31536000"
+
+	resp := httptest.NewRecorder()
+	handle(resp, showRequest)
+	eq(t, "Plain HTTP", resp.Header().Get("Strict-Transport-Security"), "")
+
+	req, _ := http.NewRequest("GET", "https://localhost/hotels/3", nil)
+	req.TLS = &tls.ConnectionState{}
+	resp = httptest.NewRecorder()
+	handle(resp, req)
+	eq(t, "HTTPS", resp.Header().Get("Strict-Transport-Security"), "max-age=31536000")
+}
+
+// Test that an address that is a path is listened on as a unix socket.
+func TestListenUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "revel-socket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := path.Join(dir, "app.sock")
+
+	// Listen twice, to check that a stale socket is replaced.
+	for i := 0; i < 2; i++ {
+		listener, err := listen(socket)
+		if err != nil {
+			t.Fatal("Failed to listen on unix socket:", err)
+		}
+		eq(t, "Network", listener.Addr().Network(), "unix")
+		if unixListener, ok := listener.(*net.UnixListener); ok {
+			unixListener.SetUnlinkOnClose(false)
+		}
+		listener.Close()
+	}
+}
+
+func TestServerAddress(t *testing.T) {
+	eq(t, "Host and port", serverAddress("127.0.0.1", 9000), "127.0.0.1:9000")
+	eq(t, "Any host", serverAddress("", 9000), ":9000")
+	eq(t, "No port", serverAddress("127.0.0.1", 0), "127.0.0.1")
+	eq(t, "Socket", serverAddress("/var/run/app.sock", 9000), "/var/run/app.sock")
+
+	defer func(addr string, port int) { HttpAddr, HttpPort = addr, port }(HttpAddr, HttpPort)
+	HttpAddr, HttpPort = "/var/run/app.sock", 9000
+	eq(t, "Default host", defaultHttpHost(), "localhost")
+}
+
 func getFileSize(t *testing.T, name string) int64 {
 	fi, err := os.Stat(name)
 	if err != nil {
@@ -100,4 +272,6 @@ var (
 	staticRequest, _    = http.NewRequest("GET", "/public/js/sessvars.js", nil)
 	jsonRequest, _      = http.NewRequest("GET", "/hotels/3/booking", nil)
 	plaintextRequest, _ = http.NewRequest("GET", "/hotels", nil)
+	deleteRequest, _    = http.NewRequest("DELETE", "/hotels", nil)
+	optionsRequest, _   = http.NewRequest("OPTIONS", "/hotels", nil)
 )
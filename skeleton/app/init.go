@@ -5,11 +5,16 @@ import "github.com/robfig/revel"
 func init() {
 	// Filters is the default set of global filters.
 	revel.Filters = []revel.Filter{
+		revel.AccessLogFilter,         // Write the access log, if log.access.output is set.
 		revel.PanicFilter,             // Recover from panics and display an error page instead.
+		revel.SecureHeadersFilter,     // Set the security headers (e.g. Content-Security-Policy).
+		revel.MethodOverrideFilter,    // Allow forms to PUT, PATCH or DELETE by the _method field.
 		revel.RouterFilter,            // Use the routing table to select the right Action
 		revel.FilterConfiguringFilter, // A hook for adding or removing per-Action filters.
+		revel.TimeoutFilter,           // Give up on actions that take longer than http.timeout.action.
 		revel.ParamsFilter,            // Parse parameters into Controller.Params.
 		revel.SessionFilter,           // Restore and write the session cookie.
+		revel.CSRFFilter,              // Reject unsafe requests without the session's CSRF token.
 		revel.FlashFilter,             // Restore and write the flash cookie.
 		revel.ValidationFilter,        // Restore kept validation errors and save new ones from cookie.
 		revel.I18nFilter,              // Resolve the requested language
@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -43,8 +44,9 @@ var whiteSpacePattern = regexp.MustCompile(`\s+`)
 var (
 	// The functions available for use in the templates.
 	TemplateFuncs = map[string]interface{}{
-		"url": ReverseUrl,
-		"eq":  Equal,
+		"url":         ReverseUrl,
+		"absoluteUrl": ReverseAbsoluteUrl,
+		"eq":          Equal,
 		"set": func(renderArgs map[string]interface{}, key string, value interface{}) template.HTML {
 			renderArgs[key] = value
 			return template.HTML("")
@@ -387,30 +389,76 @@ func (gotmpl GoTemplate) Content() []string {
 
 // Return a url capable of invoking a given controller method:
 // "Application.ShowApp 123" => "/app/123"
+// An url.Values may be passed as the last argument to add query parameters.
 func ReverseUrl(args ...interface{}) (string, error) {
+	actionDef, err := reverseArgs(args)
+	if err != nil {
+		return "", err
+	}
+	return actionDef.Url, nil
+}
+
+// Return an absolute url (including the scheme and host) capable of invoking a
+// given controller method:
+// "Application.ShowApp 123" => "http://www.example.com/app/123"
+func ReverseAbsoluteUrl(args ...interface{}) (string, error) {
+	actionDef, err := reverseArgs(args)
+	if err != nil {
+		return "", err
+	}
+	return actionDef.AbsoluteUrl(), nil
+}
+
+// reverseArgs reverse routes the action given as the first arg, unbinding the
+// remaining args according to the action's parameters.
+func reverseArgs(args []interface{}) (*ActionDefinition, error) {
 	if len(args) == 0 {
-		return "", fmt.Errorf("no arguments provided to reverse route")
+		return nil, fmt.Errorf("no arguments provided to reverse route")
 	}
 
-	action := args[0].(string)
+	action, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("reversing %v, expected an action name", args[0])
+	}
 	actionSplit := strings.Split(action, ".")
 	if len(actionSplit) != 2 {
-		return "", fmt.Errorf("reversing '%s', expected 'Controller.Action'", action)
+		return nil, fmt.Errorf("reversing '%s', expected 'Controller.Action'", action)
 	}
 
 	// Look up the types.
 	var c Controller
 	if err := c.SetAction(actionSplit[0], actionSplit[1]); err != nil {
-		return "", fmt.Errorf("reversing %s: %s", action, err)
+		return nil, fmt.Errorf("reversing %s: %s", action, err)
+	}
+
+	// Additional query parameters may be provided last.
+	argValues := args[1:]
+	query, hasQuery := url.Values(nil), false
+	if len(argValues) > 0 {
+		query, hasQuery = argValues[len(argValues)-1].(url.Values)
+		if hasQuery {
+			argValues = argValues[:len(argValues)-1]
+		}
+	}
+	if len(argValues) > len(c.MethodType.Args) {
+		return nil, fmt.Errorf("reversing %s: expected at most %d arguments, got %d",
+			action, len(c.MethodType.Args), len(argValues))
 	}
 
 	// Unbind the arguments.
 	argsByName := make(map[string]string)
-	for i, argValue := range args[1:] {
+	for i, argValue := range argValues {
 		Unbind(argsByName, c.MethodType.Args[i].Name, argValue)
 	}
+	values := make(url.Values, len(argsByName)+len(query))
+	for k, v := range argsByName {
+		values.Set(k, v)
+	}
+	for k, v := range query {
+		values[k] = append(values[k], v...)
+	}
 
-	return MainRouter.Reverse(args[0].(string), argsByName).Url, nil
+	return MainRouter.ReverseValues(action, values)
 }
 
 func Slug(text string) string {
@@ -0,0 +1,252 @@
+package revel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// The action timeout, from app.conf.
+var (
+	actionTimeout       time.Duration
+	actionTimeoutStatus int
+)
+
+// Timeouts for particular controllers or actions, keyed like filterOverrides,
+// e.g. "App" or "App.Action".
+var actionTimeouts = make(map[string]time.Duration)
+
+func init() {
+	OnAppStart(func() {
+		actionTimeout = Config.DurationDefault("http.timeout.action", 0)
+		actionTimeoutStatus = Config.IntDefault("http.timeout.status", http.StatusServiceUnavailable)
+	})
+}
+
+// TimeoutFilter gives up on requests that take too long, answering them with
+// an error (by default, 503 Service Unavailable).  It is configured in
+// app.conf, for example:
+//   http.timeout.action = 30s
+//   http.timeout.status = 504
+//
+// The rest of the filter chain, and the action, run in their own goroutine,
+// using a copy of the controller.  When the timeout passes, the request's
+// context (c.Request.Context()) is cancelled, and anything the action goes on
+// to write to the response is discarded.  The result is applied as usual once
+// the action returns, so rendering it is not subject to the timeout.
+//
+// Other timeouts may be given to particular controllers or actions:
+//   revel.FilterAction(Reports.Generate).
+//     Timeout(5 * time.Minute)
+func TimeoutFilter(c *Controller, fc []Filter) {
+	timeout, ok := actionTimeouts[c.Action]
+	if !ok {
+		if timeout, ok = actionTimeouts[c.Name]; !ok {
+			timeout = actionTimeout
+		}
+	}
+
+	// Websockets are expected to stay open.
+	if timeout <= 0 || c.Request.Websocket != nil {
+		fc[0](c, fc[1:])
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+
+	var (
+		writer   = newTimeoutWriter(c.Response.Out)
+		detached = detachController(c, ctx, writer)
+		done     = make(chan struct{})
+		panicked = make(chan *goroutinePanic, 1)
+	)
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				panicked <- &goroutinePanic{err, string(debug.Stack())}
+				return
+			}
+			close(done)
+		}()
+		fc[0](detached, fc[1:])
+	}()
+
+	select {
+	case <-done:
+		// Carry on with the detached controller, copying its request and response
+		// back into the originals, which the caller still refers to.  The request
+		// keeps its original context.
+		writer.release()
+		request, response := c.Request, c.Response
+		httpRequest := request.Request
+		*request, *response = *detached.Request, *detached.Response
+		request.Request = httpRequest
+		*c = *detached
+		c.Request, c.Response = request, response
+		if c.AppController != nil {
+			pointAppController(c.AppController, c.Type, c)
+		}
+	case err := <-panicked:
+		// Panic here, so that the PanicFilter may recover.  It reports the stack
+		// of the action's goroutine.
+		panic(err)
+	case <-ctx.Done():
+		if !writer.timeout() {
+			ERROR.Printf("Request %s: %s timed out after writing part of the response", c.RequestId, c.Action)
+			return
+		}
+		WARN.Printf("Request %s: %s timed out after %s", c.RequestId, c.Action, timeout)
+		c.Response.Status = actionTimeoutStatus
+		c.Result = c.RenderError(&Error{
+			Title:       http.StatusText(actionTimeoutStatus),
+			Description: fmt.Sprintf("The request took longer than %s", timeout),
+		})
+	}
+}
+
+// detachController returns a copy of the controller, which shares nothing that
+// the rest of the filter chain might change with the original.
+func detachController(c *Controller, ctx context.Context, out http.ResponseWriter) *Controller {
+	detached := *c
+	request := *c.Request
+	request.Request = c.Request.WithContext(ctx)
+	detached.Request = &request
+	detached.Response = &Response{Status: c.Response.Status, ContentType: c.Response.ContentType, Out: out}
+	params := *c.Params
+	detached.Params = &params
+	detached.Args = copyArgs(c.Args)
+	detached.RenderArgs = copyArgs(c.RenderArgs)
+	if c.Session != nil {
+		detached.Session = make(Session, len(c.Session))
+		for key, value := range c.Session {
+			detached.Session[key] = value
+		}
+	}
+	if c.Flash.Data != nil {
+		detached.Flash = Flash{copyStrings(c.Flash.Data), copyStrings(c.Flash.Out)}
+	}
+	if c.Validation != nil {
+		validation := *c.Validation
+		detached.Validation = &validation
+	}
+	if c.AppController != nil {
+		// Copy the app controller, keeping any state set by interceptors that
+		// have already run.
+		original := reflect.ValueOf(c.AppController).Elem()
+		appController := reflect.New(original.Type())
+		appController.Elem().Set(original)
+		detached.AppController = appController.Interface()
+		pointAppController(detached.AppController, c.Type, &detached)
+	}
+	return &detached
+}
+
+// pointAppController sets the Controllers embedded in the app controller.
+func pointAppController(appController interface{}, appControllerType *ControllerType, c *Controller) {
+	value := reflect.ValueOf(appController).Elem()
+	for _, index := range appControllerType.ControllerIndexes {
+		value.FieldByIndex(index).Set(reflect.ValueOf(c))
+	}
+}
+
+func copyArgs(m map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		result[key] = value
+	}
+	return result
+}
+
+func copyStrings(m map[string]string) map[string]string {
+	result := make(map[string]string, len(m))
+	for key, value := range m {
+		result[key] = value
+	}
+	return result
+}
+
+// timeoutWriter passes writes through to the response until the request times
+// out, after which they are discarded.  It keeps its own headers until the
+// response is written, so that an abandoned action can not change them.
+type timeoutWriter struct {
+	out    http.ResponseWriter
+	header http.Header
+
+	mu       sync.Mutex
+	wrote    bool
+	timedOut bool
+}
+
+func newTimeoutWriter(out http.ResponseWriter) *timeoutWriter {
+	header := make(http.Header)
+	for key, values := range out.Header() {
+		header[key] = append([]string(nil), values...)
+	}
+	return &timeoutWriter{out: out, header: header}
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.timedOut && !w.wrote {
+		w.writeHeader(status)
+	}
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !w.wrote {
+		w.writeHeader(http.StatusOK)
+	}
+	return w.out.Write(b)
+}
+
+func (w *timeoutWriter) writeHeader(status int) {
+	header := w.out.Header()
+	for key, values := range w.header {
+		header[key] = values
+	}
+	w.wrote = true
+	w.out.WriteHeader(status)
+}
+
+// release writes the headers through to the response, once the action has
+// returned in time.  Further changes to the headers are made directly.
+func (w *timeoutWriter) release() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	header := w.out.Header()
+	for key, values := range w.header {
+		header[key] = values
+	}
+	w.header = header
+}
+
+// timeout discards any further writes.  It returns false if the response has
+// already been started.
+func (w *timeoutWriter) timeout() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timedOut = true
+	return !w.wrote
+}
+
+// Timeout sets the timeout applied by the TimeoutFilter to the controller or
+// action, in place of http.timeout.action.  A timeout of 0 means none.
+func (conf FilterConfigurator) Timeout(timeout time.Duration) FilterConfigurator {
+	actionTimeouts[conf.key] = timeout
+	return conf
+}
@@ -0,0 +1,125 @@
+package revel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeoutFilter(t *testing.T) {
+	startFakeBookingApp()
+	defer func(timeout time.Duration) { actionTimeout = timeout }(actionTimeout)
+	newController := func() (*Controller, *httptest.ResponseRecorder) {
+		c, resp := testController(showRequest)
+		c.Session = make(Session)
+		return c, resp
+	}
+
+	// A request that finishes in time carries on as usual.
+	actionTimeout = time.Second
+	c, resp := newController()
+	TimeoutFilter(c, []Filter{func(c *Controller, _ []Filter) {
+		c.Session["user"] = "alice"
+		c.Response.Out.Header().Set("X-Action", "done")
+		c.Result = c.RenderText("hello")
+	}})
+	eq(t, "Session", c.Session["user"], "alice")
+	if c.Result == nil {
+		t.Fatal("Expected the action's result")
+	}
+	c.Result.Apply(c.Request, c.Response)
+	eq(t, "Body", resp.Body.String(), "hello")
+	eq(t, "Header", resp.Header().Get("X-Action"), "done")
+
+	// A slow request is answered with an error, and its late writes discarded.
+	defer func(status int) { actionTimeoutStatus = status }(actionTimeoutStatus)
+	actionTimeout, actionTimeoutStatus = 10*time.Millisecond, http.StatusGatewayTimeout
+	c, resp = newController()
+	cancelled := make(chan error)
+	finished := make(chan struct{})
+	TimeoutFilter(c, []Filter{func(c *Controller, _ []Filter) {
+		defer close(finished)
+		<-c.Request.Context().Done()
+		cancelled <- c.Request.Context().Err()
+		c.Session["user"] = "bob"
+		c.Response.Out.Header().Set("X-Action", "late")
+		c.Response.Out.Write([]byte("late"))
+	}})
+	eq(t, "Context error", <-cancelled, context.DeadlineExceeded)
+	<-finished
+	eq(t, "Status", c.Response.Status, http.StatusGatewayTimeout)
+	if _, ok := c.Result.(ErrorResult); !ok {
+		t.Errorf("Expected an ErrorResult, got %#v", c.Result)
+	}
+	eq(t, "Session", c.Session["user"], "")
+	eq(t, "Body", resp.Body.String(), "")
+	eq(t, "Header", resp.Header().Get("X-Action"), "")
+
+	// A panic is raised in the calling goroutine, with the stack of the action.
+	func() {
+		defer func() {
+			err, stack := panicStack(recover())
+			eq(t, "Recovered", err, "boom")
+			if !strings.Contains(stack, "timeout_test.go") || strings.Contains(stack, "testing.tRunner") {
+				t.Errorf("Expected the stack of the action, got %s", stack)
+			}
+		}()
+		actionTimeout = time.Second
+		c, _ = newController()
+		TimeoutFilter(c, []Filter{func(*Controller, []Filter) {
+			panic("boom")
+		}})
+	}()
+}
+
+// Test that timeouts may be set for particular actions, and that the app
+// controller follows the controller that the chain runs with.
+func TestTimeoutFilterAction(t *testing.T) {
+	startFakeBookingApp()
+	defer func(timeout time.Duration) { actionTimeout = timeout }(actionTimeout)
+	defer delete(actionTimeouts, "Hotels.Show")
+	actionTimeout = time.Millisecond
+	FilterAction(Hotels.Show).Timeout(time.Second)
+
+	c, _ := testController(showRequest)
+	if err := c.SetAction("Hotels", "Show"); err != nil {
+		t.Fatal(err)
+	}
+	original := c.AppController
+	TimeoutFilter(c, []Filter{func(detached *Controller, _ []Filter) {
+		time.Sleep(10 * time.Millisecond)
+		if detached.AppController == original {
+			t.Error("Expected a copy of the app controller")
+		}
+		eq(t, "Detached app controller", detached.AppController.(*Hotels).Controller, detached)
+		detached.Result = detached.RenderText("shown")
+	}})
+	if _, ok := c.Result.(*RenderTextResult); !ok {
+		t.Fatalf("Expected the action's result, got %#v", c.Result)
+	}
+	eq(t, "App controller", c.AppController.(*Hotels).Controller, c)
+}
+
+// Test that what the chain sets on the response survives the timeout, when the
+// request is served by handle.
+func TestTimeoutFilterResponse(t *testing.T) {
+	startFakeBookingApp()
+	defer func(filters []Filter, timeout time.Duration) {
+		Filters, actionTimeout = filters, timeout
+	}(Filters, actionTimeout)
+	actionTimeout = time.Second
+	Filters = []Filter{TimeoutFilter, func(c *Controller, _ []Filter) {
+		c.Response.Status = http.StatusCreated
+		c.SetCookie(&http.Cookie{Name: "session", Value: "abc"})
+		c.Result = c.RenderText("created")
+	}}
+
+	resp := httptest.NewRecorder()
+	handle(resp, showRequest)
+	eq(t, "Status", resp.Code, http.StatusCreated)
+	eq(t, "Cookie", resp.Header().Get("Set-Cookie"), "session=abc")
+	eq(t, "Body", resp.Body.String(), "created")
+}
@@ -0,0 +1,157 @@
+package revel
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The values accepted by http.sslminversion.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// The values accepted by http.sslclientauth.
+var tlsClientAuthTypes = map[string]tls.ClientAuthType{
+	"none":     tls.NoClientCert,
+	"optional": tls.VerifyClientCertIfGiven,
+	"required": tls.RequireAndVerifyClientCert,
+}
+
+// How often the certificate files are checked for changes.
+var certCheckInterval = 10 * time.Second
+
+// newTlsConfig returns the TLS configuration for the server, as configured in
+// app.conf.  For example:
+//   http.sslminversion = 1.2
+//   http.sslciphers = TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384
+//   http.sslclientauth = required
+//   http.sslclientca = /path/to/ca.pem
+//
+// The certificate and key are reloaded when they change on disk.
+func newTlsConfig() (*tls.Config, error) {
+	reloader, err := newCertReloader(HttpSslCert, HttpSslKey)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{GetCertificate: reloader.GetCertificate}
+
+	if version := Config.StringDefault("http.sslminversion", ""); version != "" {
+		var ok bool
+		if config.MinVersion, ok = tlsVersions[version]; !ok {
+			return nil, fmt.Errorf("Unknown http.sslminversion: %s", version)
+		}
+	}
+
+	if ciphers := Config.StringDefault("http.sslciphers", ""); ciphers != "" {
+		for _, name := range strings.Split(ciphers, ",") {
+			id, ok := cipherSuite(strings.TrimSpace(name))
+			if !ok {
+				return nil, fmt.Errorf("Unknown cipher suite in http.sslciphers: %s", name)
+			}
+			config.CipherSuites = append(config.CipherSuites, id)
+		}
+	}
+
+	if clientAuth := Config.StringDefault("http.sslclientauth", ""); clientAuth != "" {
+		var ok bool
+		if config.ClientAuth, ok = tlsClientAuthTypes[clientAuth]; !ok {
+			return nil, fmt.Errorf("Unknown http.sslclientauth: %s", clientAuth)
+		}
+	}
+
+	if caFile := Config.StringDefault("http.sslclientca", ""); caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("No certificates found in http.sslclientca: %s", caFile)
+		}
+	} else if config.ClientAuth != tls.NoClientCert {
+		return nil, fmt.Errorf("http.sslclientauth requires http.sslclientca")
+	}
+
+	return config, nil
+}
+
+// cipherSuite returns the ID of the named cipher suite.
+func cipherSuite(name string) (uint16, bool) {
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		if suite.Name == name {
+			return suite.ID, true
+		}
+	}
+	return 0, false
+}
+
+// certReloader provides the server certificate, reloading it when the
+// certificate or key file changes.
+type certReloader struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time // The latest modification time of the files loaded.
+	checked time.Time // When the files were last checked for changes.
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the current certificate, first reloading it if the
+// files have changed.  If the new files fail to load, the previous certificate
+// continues to be used.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checked) >= certCheckInterval {
+		if err := r.reload(); err != nil {
+			ERROR.Println("Failed to reload certificate:", err)
+		}
+	}
+	return r.cert, nil
+}
+
+// reload loads the certificate if either file has been modified since it was
+// last loaded.
+func (r *certReloader) reload() error {
+	r.checked = time.Now()
+	var modTime time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	if r.cert != nil && modTime.Equal(r.modTime) {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	if r.cert != nil {
+		INFO.Println("Reloaded certificate", r.certFile)
+	}
+	r.cert, r.modTime = &cert, modTime
+	return nil
+}
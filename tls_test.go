@@ -0,0 +1,151 @@
+package revel
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a self-signed certificate and its key for the given
+// name into the directory, returning the paths to the files.
+func writeTestCert(t *testing.T, dir, name string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	if err == nil {
+		err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func certName(t *testing.T, cert *tls.Certificate) string {
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	defer func(interval time.Duration) { certCheckInterval = interval }(certCheckInterval)
+	dir, err := ioutil.TempDir("", "revel-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := writeTestCert(t, dir, "first")
+	reloader, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal("Failed to load certificate:", err)
+	}
+	cert, _ := reloader.GetCertificate(nil)
+	eq(t, "Certificate", certName(t, cert), "first")
+
+	// Rotate the certificate, and check that it is only picked up once the
+	// check interval has passed.
+	writeTestCert(t, dir, "second")
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	cert, _ = reloader.GetCertificate(nil)
+	eq(t, "Certificate before the check", certName(t, cert), "first")
+
+	certCheckInterval = 0
+	cert, _ = reloader.GetCertificate(nil)
+	eq(t, "Certificate after the check", certName(t, cert), "second")
+
+	// A broken certificate leaves the previous one in place.
+	ioutil.WriteFile(certFile, []byte("garbage"), 0600)
+	os.Chtimes(certFile, later.Add(time.Minute), later.Add(time.Minute))
+	cert, _ = reloader.GetCertificate(nil)
+	eq(t, "Certificate after a failed reload", certName(t, cert), "second")
+}
+
+func TestTlsConfig(t *testing.T) {
+	startFakeBookingApp()
+	dir, err := ioutil.TempDir("", "revel-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	HttpSslCert, HttpSslKey = writeTestCert(t, dir, "server")
+	defer func() { HttpSslCert, HttpSslKey = "", "" }()
+
+	Config.SetOption("http.sslminversion", "1.2")
+	Config.SetOption("http.sslciphers", "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384")
+	Config.SetOption("http.sslclientauth", "optional")
+	Config.SetOption("http.sslclientca", HttpSslCert)
+	config, err := newTlsConfig()
+	if err != nil {
+		t.Fatal("Failed to configure TLS:", err)
+	}
+	eq(t, "MinVersion", config.MinVersion, uint16(tls.VersionTLS12))
+	eq(t, "CipherSuites", len(config.CipherSuites), 2)
+	eq(t, "ClientAuth", config.ClientAuth, tls.VerifyClientCertIfGiven)
+	if config.ClientCAs == nil {
+		t.Error("Expected the client CA bundle to be loaded")
+	}
+
+	for option, value := range map[string]string{
+		"http.sslminversion": "1.9",
+		"http.sslciphers":    "TLS_NOT_A_CIPHER",
+		"http.sslclientauth": "sometimes",
+		"http.sslclientca":   "",
+	} {
+		startFakeBookingApp()
+		Config.SetOption("http.sslclientauth", "required")
+		Config.SetOption("http.sslclientca", HttpSslCert)
+		Config.SetOption(option, value)
+		if _, err := newTlsConfig(); err == nil {
+			t.Errorf("Expected an error for %s = %s", option, value)
+		}
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	clientCert := &x509.Certificate{Subject: pkix.Name{CommonName: "client"}}
+	req, _ := http.NewRequest("GET", "https://localhost/", nil)
+	eq(t, "No TLS", NewRequest(req).ClientCertificate == nil, true)
+
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{clientCert}}
+	eq(t, "Unverified", NewRequest(req).ClientCertificate == nil, true)
+
+	req.TLS.VerifiedChains = [][]*x509.Certificate{{clientCert}}
+	eq(t, "Verified", NewRequest(req).ClientCertificate, clientCert)
+}